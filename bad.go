@@ -0,0 +1,192 @@
+// bad.go - mark unhealthy objects for refresh on return
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// MarkBad flags the checked out object 'x' as unhealthy. When 'x' is
+// next returned via Put, the pool refreshes it instead of running the
+// reset hook: the cleanup hook (if any) releases its resources and it
+// is overwritten with a fresh object (see WithReplacement) before going
+// back in rotation. This saves callers from keeping external state
+// keyed by pointer to track objects that errored. Refreshes are counted
+// in Stats.BadRefreshes.
+//
+// MarkBad returns ErrForeign if 'x' isn't part of the backing array and
+// ErrNotCheckedOut if it's free.
+func (p *Pool[T]) MarkBad(x *T) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.slot(x)
+	if i < 0 {
+		return ErrForeign
+	}
+	if !p.checkedOut(i, x) {
+		return ErrNotCheckedOut
+	}
+
+	if p.bad == nil {
+		p.bad = newBitset(p.size)
+	}
+	if !p.bad.isset(i) {
+		p.bad.set(i)
+		p.nbad.Add(1)
+		p.slow.Store(true)
+	}
+	return nil
+}
+
+// takeBad returns true if 'x' was marked bad and clears the mark
+func (p *Pool[T]) takeBad(x *T) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.slot(x)
+	if i < 0 || p.bad == nil || !p.bad.isset(i) {
+		return false
+	}
+
+	p.bad.clr(i)
+	p.nbad.Add(-1)
+	p.badRefreshes += 1
+	return true
+}
+
+// PutIf returns 'x' to the pool if keep(x) is true and returns true.
+// Otherwise the object is discarded: since the pool has a fixed backing
+// array, discarding means the cleanup hook (if any) releases its
+// resources and the slot is overwritten with a fresh object (see
+// WithReplacement) before going back in rotation, so the capacity is
+// maintained. Discards are counted in Stats.Discards. 'keep' runs
+// without the pool lock held and lets each call site apply its own
+// retention logic.
+func (p *Pool[T]) PutIf(x *T, keep func(*T) bool) bool {
+	if p.frozen.Load() {
+		return true
+	}
+	if p.fallback(x) {
+		return false
+	}
+	if keep(x) {
+		p.Put(x)
+		return true
+	}
+
+	if s := p.discardOne(x); s != nil {
+		s.spillIn(x)
+	}
+	return false
+}
+
+// discardOne refreshes 'x' and enqueues it; like putOne it returns the
+// spillover pool if 'x' must go there instead. Duplicates and overflows
+// are caught before 'x' is refreshed.
+func (p *Pool[T]) discardOne(x *T) *Pool[T] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.checkPut(x) {
+		return nil
+	}
+
+	var s *Pool[T]
+	if p.avail == p.size {
+		if s = p.full(x, 0); s == nil {
+			return nil
+		}
+	}
+
+	p.refresh(x)
+	p.discards += 1
+	if s == nil {
+		p.put(x, false)
+	}
+	return s
+}
+
+// refresh releases the resources of 'x' and overwrites it with a fresh
+// object.
+func (p *Pool[T]) refresh(x *T) {
+	if p.cleanup != nil {
+		p.cleanup(x)
+	}
+	p.renew(x)
+}
+
+// renew overwrites the discarded object 'x' with one built by the
+// replacement constructor, or with a zero value if there is none.
+func (p *Pool[T]) renew(x *T) {
+	if p.replace != nil {
+		*x = p.replace()
+		return
+	}
+
+	var zero T
+	*x = zero
+}
+
+// PutPoison returns 'x' to the pool but flags its slot as being in an
+// irrecoverable state: the next Get that hands out the slot rebuilds
+// the object first - via the constructor set with WithReplacement, the
+// init func of NewWithInit or NewIface, or by overwriting it with a zero
+// value for other pools. This keeps the slot in rotation after a clean
+// rebuild. The rebuild runs under the pool lock and is counted in
+// Stats.PoisonRebuilds. The reset hook isn't run on poisoned objects.
+func (p *Pool[T]) PutPoison(x *T) {
+	if p.frozen.Load() || p.fallback(x) {
+		return
+	}
+
+	if s := p.poisonOne(x); s != nil {
+		s.spillIn(x)
+	}
+}
+
+// poisonOne flags the slot of 'x' and enqueues it; like putOne it
+// returns the spillover pool if 'x' must go there instead. Objects that
+// spill aren't part of the pool and have no slot to flag.
+func (p *Pool[T]) poisonOne(x *T) *Pool[T] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.checkPut(x) {
+		return nil
+	}
+	if p.avail == p.size {
+		return p.full(x, 0)
+	}
+
+	if i := p.slot(x); i >= 0 {
+		if p.poison == nil {
+			p.poison = newBitset(p.size)
+			p.replain()
+		}
+		p.poison.set(i)
+	}
+	p.put(x, false)
+	return nil
+}
+
+// rebuild reconstructs 'x' if its slot is poisoned; the caller must
+// hold the lock.
+func (p *Pool[T]) rebuild(x *T) {
+	i := p.slot(x)
+	if i < 0 || !p.poison.isset(i) {
+		return
+	}
+
+	p.poison.clr(i)
+	p.poisonRebuilds += 1
+	if p.replace != nil {
+		*x = p.replace()
+		return
+	}
+
+	var zero T
+	*x = zero
+	if p.init != nil {
+		p.init(x)
+	}
+}
@@ -0,0 +1,63 @@
+// batch.go - release a set of objects as a unit
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// Batch accumulates objects acquired from a pool one at a time so they
+// can be returned together. It's meant for code that acquires a set of
+// objects through separate (possibly blocking) Gets and must release
+// all of them if it fails or is cancelled part of the way:
+//
+//	b := p.NewBatch()
+//	defer b.Release()
+//	for i := 0; i < n; i++ {
+//		x, err := p.GetContext(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		b.Add(x)
+//	}
+//
+// A Batch is not safe for concurrent use.
+type Batch[T any] struct {
+	p *Pool[T]
+	v []*T
+}
+
+// NewBatch returns an empty batch for objects of the pool
+func (p *Pool[T]) NewBatch() *Batch[T] {
+	return &Batch[T]{p: p}
+}
+
+// Add records 'x' as part of the batch; nil objects (e.g. from a Get
+// on an exhausted pool) are ignored.
+func (b *Batch[T]) Add(x *T) {
+	if x != nil {
+		b.v = append(b.v, x)
+	}
+}
+
+// Objs returns the objects in the batch in the order they were added
+func (b *Batch[T]) Objs() []*T {
+	return b.v
+}
+
+// Len returns the number of objects in the batch
+func (b *Batch[T]) Len() int {
+	return len(b.v)
+}
+
+// Release returns every object in the batch to the pool via PutN and
+// empties the batch; releasing an empty batch is a no-op, so Release
+// can be deferred and still called explicitly.
+func (b *Batch[T]) Release() {
+	if len(b.v) == 0 {
+		return
+	}
+
+	b.p.PutN(b.v)
+	clear(b.v)
+	b.v = b.v[:0]
+}
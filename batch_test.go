@@ -0,0 +1,50 @@
+package objpool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestBatch(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](3)
+
+	acquire := func(ctx context.Context, n int) error {
+		b := o.NewBatch()
+		defer b.Release()
+
+		for i := 0; i < n; i++ {
+			x, err := o.GetContext(ctx)
+			if err != nil {
+				return err
+			}
+			b.Add(x)
+		}
+		assert(b.Len() == n, "batch: exp %d, saw %d", n, b.Len())
+		return nil
+	}
+
+	// the pool runs dry part of the way; everything acquired so far is
+	// released
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := acquire(ctx, 5)
+	assert(err == context.DeadlineExceeded, "exp deadline, saw %v", err)
+	assert(o.Avail() == 3, "avail: exp 3, saw %d", o.Avail())
+
+	err = acquire(context.Background(), 3)
+	assert(err == nil, "acquire: %v", err)
+	assert(o.Avail() == 3, "avail: exp 3, saw %d", o.Avail())
+
+	b := o.NewBatch()
+	b.Add(o.Get())
+	b.Add(nil)
+	assert(b.Len() == 1, "nil added to batch")
+	b.Release()
+	b.Release()
+	assert(o.Avail() == 3 && b.Len() == 0, "double release")
+}
@@ -0,0 +1,283 @@
+package objpool_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+// refPool is a verbatim copy of the original hook-less pool; it serves
+// as the baseline of the fast path of Pool.
+type refPool[T any] struct {
+	mu sync.Mutex
+
+	rd, wr int
+	avail  int
+
+	q   []*T
+	arr []T
+}
+
+func newRefPool[T any](sz int) *refPool[T] {
+	arr := make([]T, sz)
+	q := make([]*T, sz)
+	for i := range arr {
+		q[i] = &arr[i]
+	}
+	return &refPool[T]{avail: sz, q: q, arr: arr}
+}
+
+func (p *refPool[T]) Get() *T {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == 0 {
+		return nil
+	}
+
+	var rd int
+	rd, p.rd = p.rd, p.inc(p.rd)
+	p.avail -= 1
+	return p.q[rd]
+}
+
+func (p *refPool[T]) Put(x *T) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == len(p.q) {
+		msg := fmt.Sprintf("%T: unexpected q-full", p)
+		panic(msg)
+	}
+
+	var wr int
+	wr, p.wr = p.wr, p.inc(p.wr)
+	p.avail += 1
+	p.q[wr] = x
+}
+
+func (p *refPool[T]) inc(i int) int {
+	if i = i + 1; i >= len(p.q) {
+		i = 0
+	}
+	return i
+}
+
+type getPutter[T any] interface {
+	Get() *T
+	Put(*T)
+}
+
+func benchGetPut(b *testing.B, p getPutter[[64]byte]) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		x := p.Get()
+		p.Put(x)
+	}
+}
+
+func BenchmarkRefGetPut(b *testing.B) {
+	benchGetPut(b, newRefPool[[64]byte](1024))
+}
+
+func BenchmarkGetPut(b *testing.B) {
+	benchGetPut(b, objpool.New[[64]byte](1024))
+}
+
+func BenchmarkGetPutReset(b *testing.B) {
+	reset := func(x *[64]byte) error {
+		x[0] = 0
+		return nil
+	}
+	benchGetPut(b, objpool.New[[64]byte](1024, objpool.WithReset(reset)))
+}
+
+func BenchmarkGetPutParallel(b *testing.B) {
+	p := objpool.New[[64]byte](1024)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			x := p.Get()
+			p.Put(x)
+		}
+	})
+}
+
+func BenchmarkGetTimeout(b *testing.B) {
+	p := objpool.New[[64]byte](1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		x := p.GetTimeout(time.Second)
+		p.Put(x)
+	}
+}
+
+func BenchmarkGetBatch(b *testing.B) {
+	p := objpool.New[[64]byte](1024)
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v := p.GetBatch(ctx, 16, 0)
+		for _, x := range v {
+			p.Put(x)
+		}
+	}
+}
+
+// contended get/put where the pool is much smaller than the number of
+// goroutines and callers block for objects.
+func BenchmarkGetTimeoutContended(b *testing.B) {
+	p := objpool.New[[64]byte](4)
+	b.ReportAllocs()
+	b.SetParallelism(8)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			x := p.GetTimeout(time.Second)
+			if x != nil {
+				p.Put(x)
+			}
+		}
+	})
+}
+
+// an init func with non-trivial cost
+func slowInit(x *[64]byte) {
+	h := sha256.New()
+	for i := 0; i < 16; i++ {
+		h.Write(x[:])
+		h.Sum(x[:0])
+	}
+}
+
+func BenchmarkNewWithInit(b *testing.B) {
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			objpool.NewWithInit[[64]byte](16384, slowInit)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			objpool.NewWithInit[[64]byte](16384, slowInit, objpool.WithParallelInit[[64]byte]())
+		}
+	})
+}
+
+func BenchmarkGetPutCompact(b *testing.B) {
+	benchGetPut(b, objpool.New[[64]byte](1024, objpool.WithCompact[[64]byte]()))
+}
+
+// B/op is the memory cost of a pool of 64k small objects
+func BenchmarkNewMem(b *testing.B) {
+	const size = 65536
+	b.Run("pointer", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			objpool.New[uint64](size)
+		}
+	})
+	b.Run("compact", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			objpool.New[uint64](size, objpool.WithCompact[uint64]())
+		}
+	})
+	b.Run("bitmap", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			objpool.NewOrdered[uint64](size)
+		}
+	})
+}
+
+// the free list representations of a large pool with most of it
+// checked out; the bitmap pays for finding a free slot.
+func BenchmarkGetPutLarge(b *testing.B) {
+	const size = 1 << 20
+
+	bench := func(b *testing.B, p getPutter[uint64]) {
+		for i := 0; i < size-size/8; i++ {
+			p.Get()
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			x := p.Get()
+			y := p.Get()
+			p.Put(x)
+			p.Put(y)
+		}
+	}
+
+	b.Run("pointer", func(b *testing.B) {
+		bench(b, objpool.New[uint64](size))
+	})
+	b.Run("compact", func(b *testing.B) {
+		bench(b, objpool.New[uint64](size, objpool.WithCompact[uint64]()))
+	})
+	b.Run("bitmap", func(b *testing.B) {
+		bench(b, objpool.NewOrdered[uint64](size))
+	})
+}
+
+func benchContended(b *testing.B, p getPutter[[64]byte]) {
+	b.ReportAllocs()
+	b.SetParallelism(8)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if x := p.Get(); x != nil {
+				p.Put(x)
+			}
+		}
+	})
+}
+
+func BenchmarkContendedMutex(b *testing.B) {
+	benchContended(b, objpool.New[[64]byte](64))
+}
+
+func BenchmarkContendedLockFree(b *testing.B) {
+	benchContended(b, objpool.NewLockFree[[64]byte](64))
+}
+
+// benchInline compares the pointer pool against the inline value pool
+// for a value of type T that is modified while checked out.
+func benchInline[T any](b *testing.B, touch func(T) T) {
+	b.Run("pointer", func(b *testing.B) {
+		p := objpool.New[T](64)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			x := p.Get()
+			*x = touch(*x)
+			p.Put(x)
+		}
+	})
+
+	b.Run("inline", func(b *testing.B) {
+		p := objpool.NewInlinePool[T](64)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			v, _ := p.Get()
+			p.Put(touch(v))
+		}
+	})
+}
+
+func BenchmarkInline(b *testing.B) {
+	b.Run("8", func(b *testing.B) {
+		benchInline(b, func(x [1]uint64) [1]uint64 { x[0]++; return x })
+	})
+	b.Run("16", func(b *testing.B) {
+		benchInline(b, func(x [2]uint64) [2]uint64 { x[0]++; return x })
+	})
+	b.Run("32", func(b *testing.B) {
+		benchInline(b, func(x [4]uint64) [4]uint64 { x[0]++; return x })
+	})
+	b.Run("64", func(b *testing.B) {
+		benchInline(b, func(x [8]uint64) [8]uint64 { x[0]++; return x })
+	})
+}
@@ -0,0 +1,49 @@
+// borrow.go - borrows with a watchdog on the return
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// BorrowWithDeadline returns a single object from the pool and a
+// closure that returns it. If the closure isn't called within 'd', the
+// hook set via WithOverdueHook is called with the object and the stack
+// of the borrower (or a warning goes to the Logger of the pool if there
+// is no hook); the object stays checked out. Calling the closure stops
+// the watchdog and returns the object via Put; calling it again is a
+// no-op.
+//
+// This turns silent leaks and slow returns into timely alerts. The stack
+// is captured on every borrow, so it's meant for leak-prone code paths
+// rather than the hot path. Like Get, BorrowWithDeadline returns nil if
+// the pool is exhausted; the closure is never nil.
+func (p *Pool[T]) BorrowWithDeadline(d time.Duration) (*T, func()) {
+	x := p.Get()
+	if x == nil {
+		return nil, func() {}
+	}
+
+	stack := debug.Stack()
+	t := time.AfterFunc(d, func() {
+		if fn := p.overdue; fn != nil {
+			fn(x, stack)
+			return
+		}
+		p.warnf("%s: %p not returned within %s; borrowed at:\n%s", p.label(), x, d, stack)
+	})
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			t.Stop()
+			p.Put(x)
+		})
+	}
+	return x, release
+}
@@ -0,0 +1,51 @@
+package objpool_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestBorrowWithDeadline(t *testing.T) {
+	assert := newAsserter(t)
+
+	type overdue struct {
+		x     *int
+		stack string
+	}
+	ch := make(chan overdue, 2)
+	hook := func(x *int, stack []byte) {
+		ch <- overdue{x, string(stack)}
+	}
+	o := objpool.New[int](2, objpool.WithOverdueHook(hook))
+
+	// returned in time: the watchdog never fires
+	x, release := o.BorrowWithDeadline(20 * time.Millisecond)
+	assert(x != nil, "borrow failed")
+	release()
+	release()
+	assert(o.Avail() == 2, "release: avail %d", o.Avail())
+
+	// held too long
+	y, release := o.BorrowWithDeadline(5 * time.Millisecond)
+	select {
+	case ov := <-ch:
+		assert(ov.x == y, "wrong object: %p", ov.x)
+		assert(strings.Contains(ov.stack, "TestBorrowWithDeadline"), "stack: %s", ov.stack)
+	case <-time.After(time.Second):
+		t.Fatalf("overdue hook didn't fire")
+	}
+	assert(o.Avail() == 1, "overdue object reclaimed")
+	release()
+	assert(o.Avail() == 2, "release: avail %d", o.Avail())
+
+	time.Sleep(30 * time.Millisecond)
+	assert(len(ch) == 0, "watchdog fired after release")
+
+	o.GetN(2)
+	z, release := o.BorrowWithDeadline(time.Millisecond)
+	assert(z == nil && release != nil, "borrow from exhausted pool")
+	release()
+}
@@ -0,0 +1,32 @@
+// chain.go - compose tiers of pools
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// GetFrom tries each pool in order and returns the first object
+// obtained; it returns nil if every pool is exhausted. This expresses
+// tiers such as "prefer the hot local pool, fall back to the shared
+// global pool".
+func GetFrom[T any](pools ...*Pool[T]) *T {
+	for _, p := range pools {
+		if x := p.Get(); x != nil {
+			return x
+		}
+	}
+	return nil
+}
+
+// PutTo returns 'x' to whichever of the pools it came from. It returns
+// false if 'x' doesn't belong to any of them; in that case 'x' isn't
+// returned anywhere.
+func PutTo[T any](x *T, pools ...*Pool[T]) bool {
+	for _, p := range pools {
+		if p.owns(x) {
+			p.Put(x)
+			return true
+		}
+	}
+	return false
+}
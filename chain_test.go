@@ -0,0 +1,54 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestGetFromPutTo(t *testing.T) {
+	assert := newAsserter(t)
+
+	hot := objpool.New[int](1)
+	cold := objpool.New[int](2)
+
+	a := objpool.GetFrom(hot, cold)
+	b := objpool.GetFrom(hot, cold)
+	c := objpool.GetFrom(hot, cold)
+	d := objpool.GetFrom(hot, cold)
+	assert(a != nil && b != nil && c != nil, "expected 3 objs")
+	assert(d == nil, "expected nil from exhausted tiers")
+	assert(hot.Avail() == 0 && cold.Avail() == 0, "tiers not drained")
+
+	assert(objpool.PutTo(b, hot, cold), "put b failed")
+	assert(cold.Avail() == 1 && hot.Avail() == 0, "b returned to wrong tier")
+
+	assert(objpool.PutTo(a, hot, cold), "put a failed")
+	assert(hot.Avail() == 1, "a returned to wrong tier")
+
+	var foreign int
+	assert(!objpool.PutTo(&foreign, hot, cold), "foreign obj accepted")
+}
+
+func TestOwns(t *testing.T) {
+	assert := newAsserter(t)
+
+	a := objpool.New[int](2)
+	b := objpool.New[int](2)
+
+	x := a.Get()
+	assert(a.Owns(x) && !b.Owns(x), "checked out obj")
+	a.Put(x)
+	assert(a.Owns(x), "free obj")
+
+	var foreign int
+	assert(!a.Owns(&foreign) && !a.Owns(nil), "foreign obj owned")
+
+	f := objpool.FromFactory(1, func() *int { return new(int) })
+	y := f.Get()
+	assert(f.Owns(y) && !a.Owns(y), "factory obj")
+
+	q := objpool.NewQueue[int](1)
+	q.Put(x)
+	assert(!q.Owns(x), "queue owns enqueued obj")
+}
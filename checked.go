@@ -0,0 +1,80 @@
+// checked.go - construction that fails gracefully for huge pools
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+	"unsafe"
+)
+
+// poolBytes returns the memory needed by a pool of 'sz' objects of
+// type 'T' configured like 'cfg': the backing array of 'sz' objects,
+// the free queue of 'sz' pointers or, for compact pools, 'sz' indices
+// and the per-slot tables of the options. It saturates at MaxUint64
+// instead of overflowing.
+func poolBytes[T any](sz int, cfg *Pool[T]) uint64 {
+	var zero T
+	var ptr *T
+	var t time.Time
+
+	q := unsafe.Sizeof(ptr)
+	if cfg.compact && unsafe.Sizeof(zero) > 0 {
+		q = unsafe.Sizeof(int32(0))
+	}
+
+	per := uint64(unsafe.Sizeof(zero)) + uint64(q)
+	if cfg.wantIdle {
+		per += uint64(unsafe.Sizeof(t))
+	}
+	if cfg.wantCheckout {
+		per += uint64(unsafe.Sizeof(t))
+	}
+
+	hi, n := bits.Mul64(uint64(sz), per)
+	if hi != 0 {
+		return math.MaxUint64
+	}
+	return n
+}
+
+// NewChecked is like New except that it returns an error instead of
+// crashing the process on a misconfigured size. A pool of 'sz' objects
+// holds two slices: the backing array of 'sz' objects and a free queue
+// of 'sz' pointers; so it needs sz * (sizeof(T) + sizeof(*T)) bytes
+// (or sz * (sizeof(T) + 4) bytes if the pool is WithCompact), plus a
+// time stamp per object for each of WithIdleTracking and
+// WithCheckoutTiming. If that exceeds the limit set via WithMemLimit,
+// NewChecked returns an error without allocating. Any panic from
+// allocating the slices (e.g. a negative or absurd size) is recovered
+// and returned as an error.
+func NewChecked[T any](sz int, opts ...Option[T]) (p *Pool[T], err error) {
+	if sz < 0 {
+		return nil, fmt.Errorf("objpool: invalid size %d", sz)
+	}
+
+	// pick up the limit before allocating anything
+	cfg := Pool[T]{size: sz}
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+
+	if n := poolBytes[T](sz, &cfg); cfg.memLimit > 0 && n > cfg.memLimit {
+		return nil, fmt.Errorf("objpool: pool of %d objects needs %d bytes; exceeds limit of %d bytes; use a smaller pool",
+			sz, n, cfg.memLimit)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			p = nil
+			err = fmt.Errorf("objpool: can't allocate pool of %d objects: %v", sz, r)
+		}
+	}()
+
+	return New[T](sz, opts...), nil
+}
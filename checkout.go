@@ -0,0 +1,61 @@
+// checkout.go - how long objects are held by callers
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"time"
+)
+
+// AvgCheckoutDuration returns the mean time objects were held between
+// Get and Put, over every object returned so far; objects that are
+// still checked out don't count until they're Put back. It's the hold
+// time, as opposed to the time it took to acquire an object: long holds
+// combined with misses point at callers that keep objects too long
+// rather than at an undersized pool. It returns 0 if no object was
+// returned yet or the pool wasn't created with WithCheckoutTiming.
+func (p *Pool[T]) AvgCheckoutDuration() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.holds == 0 {
+		return 0
+	}
+	return p.holdSum / time.Duration(p.holds)
+}
+
+// MaxCheckoutDuration returns the longest time any object was held
+// between Get and Put; like AvgCheckoutDuration it needs
+// WithCheckoutTiming.
+func (p *Pool[T]) MaxCheckoutDuration() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.holdMax
+}
+
+// checkoutStart records the time of the Get of 'x'; the caller must
+// hold the lock.
+func (p *Pool[T]) checkoutStart(x *T) {
+	if i := p.slot(x); i >= 0 {
+		p.checkout[i] = now()
+	}
+}
+
+// checkin accumulates the checkout duration of 'x' as it is Put back;
+// the caller must hold the lock. Objects without a recorded Get, e.g.
+// those checked out before a SwapBacking, are ignored.
+func (p *Pool[T]) checkin(x *T) {
+	i := p.slot(x)
+	if i < 0 || p.checkout[i].IsZero() {
+		return
+	}
+
+	d := now().Sub(p.checkout[i])
+	p.checkout[i] = time.Time{}
+	p.holds += 1
+	p.holdSum += d
+	p.holdMax = max(p.holdMax, d)
+}
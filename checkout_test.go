@@ -0,0 +1,45 @@
+package objpool_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestCheckoutDuration(t *testing.T) {
+	assert := newAsserter(t)
+
+	t0 := time.Now()
+	clk := t0
+	objpool.SetClock(func() time.Time {
+		return clk
+	})
+	defer objpool.SetClock(nil)
+
+	o := objpool.New[int](3, objpool.WithCheckoutTiming[int]())
+	assert(o.AvgCheckoutDuration() == 0, "avg of an unused pool")
+
+	a, b, c := o.Get(), o.Get(), o.Get()
+
+	clk = t0.Add(1 * time.Second)
+	o.Put(a)
+	clk = t0.Add(5 * time.Second)
+	o.Put(b)
+
+	avg, mx := o.AvgCheckoutDuration(), o.MaxCheckoutDuration()
+	assert(avg == 3*time.Second, "avg: exp 3s, saw %s", avg)
+	assert(mx == 5*time.Second, "max: exp 5s, saw %s", mx)
+
+	// c is still out and doesn't count yet
+	clk = t0.Add(20 * time.Second)
+	o.Put(c)
+	avg, mx = o.AvgCheckoutDuration(), o.MaxCheckoutDuration()
+	assert(avg == 26*time.Second/3, "avg: exp 8.67s, saw %s", avg)
+	assert(mx == 20*time.Second, "max: exp 20s, saw %s", mx)
+
+	// without the option nothing is recorded
+	p := objpool.New[int](1)
+	p.Put(p.Get())
+	assert(p.AvgCheckoutDuration() == 0 && p.MaxCheckoutDuration() == 0, "untimed pool has durations")
+}
@@ -0,0 +1,58 @@
+// clock.go - injectable time source
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// clock is the source of wall clock time for all time based logic in
+// the package.
+type clock interface {
+	Now() time.Time
+}
+
+type sysClock struct{}
+
+func (sysClock) Now() time.Time {
+	return time.Now()
+}
+
+type funcClock func() time.Time
+
+func (f funcClock) Now() time.Time {
+	return f()
+}
+
+// atomic.Value needs a consistent concrete type
+type clockBox struct {
+	clock
+}
+
+var theClock atomic.Value
+
+func init() {
+	theClock.Store(clockBox{sysClock{}})
+}
+
+// SetClock replaces the time source used by the package with 'fn'; a
+// nil 'fn' restores the default of time.Now. It exists so that tests
+// of time dependent behavior can run deterministically without
+// sleeping. Note that the timers backing blocking waits (GetTimeout,
+// GetDeadline etc.) always run on the real clock.
+func SetClock(fn func() time.Time) {
+	if fn == nil {
+		theClock.Store(clockBox{sysClock{}})
+		return
+	}
+	theClock.Store(clockBox{funcClock(fn)})
+}
+
+// now returns the current time as seen by the package clock
+func now() time.Time {
+	return theClock.Load().(clockBox).Now()
+}
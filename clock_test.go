@@ -0,0 +1,27 @@
+package objpool_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestSetClock(t *testing.T) {
+	assert := newAsserter(t)
+
+	t0 := time.Now()
+	objpool.SetClock(func() time.Time {
+		return t0.Add(time.Hour)
+	})
+	defer objpool.SetClock(nil)
+
+	o := objpool.New[int](1)
+	a := o.Get()
+	assert(a != nil, "expected obj; got nil")
+
+	// the deadline is in the past as far as the pool clock is
+	// concerned; so this must not block.
+	b := o.GetDeadline(t0.Add(time.Minute))
+	assert(b == nil, "exp nil from empty pool")
+}
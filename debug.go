@@ -0,0 +1,179 @@
+// debug.go - runtime ownership tracking for catching misuse
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"sync"
+)
+
+// debugPools is the set of pools with ownership tracking enabled; it
+// lets Put identify the pool that a misdirected object belongs to.
+// Only pools with debugging on are registered so that the registry
+// doesn't keep every pool alive.
+var debugPools sync.Map
+
+// DoubleFreeError is the value that Put and its variants panic with
+// when they detect a double free: either via ownership tracking or,
+// in builds with the objpool_debug tag, because the free queue is
+// already full. It carries enough detail for callers that recover()
+// in tests to inspect the failure.
+type DoubleFreeError struct {
+	// Pool identifies the pool by type and name (see WithName)
+	Pool string
+
+	// Ptr is the offending object (a *T)
+	Ptr any
+
+	// Slot is the slot index of Ptr or -1 if it isn't part of the pool
+	Slot int
+
+	// state of the ring when the double free was detected
+	Cap   int
+	Rd    int
+	Wr    int
+	Avail int
+}
+
+// Error returns a description of the double free
+func (e *DoubleFreeError) Error() string {
+	var what string
+	if e.Avail == e.Cap {
+		what = "unexpected q-full: "
+	}
+	return fmt.Sprintf("%s: %sdouble free of %p (slot %d); cap=%d rd=%d wr=%d avail=%d",
+		e.Pool, what, e.Ptr, e.Slot, e.Cap, e.Rd, e.Wr, e.Avail)
+}
+
+// doubleFree returns the error describing a double free of 'x'; the
+// caller must hold the lock.
+func (p *Pool[T]) doubleFree(x *T, slot int) *DoubleFreeError {
+	return &DoubleFreeError{
+		Pool:  p.label(),
+		Ptr:   x,
+		Slot:  slot,
+		Cap:   p.size,
+		Rd:    p.rd,
+		Wr:    p.wr,
+		Avail: p.avail,
+	}
+}
+
+// bitset is a simple fixed size set of slot indices
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << (uint(i) % 64)
+}
+
+func (b bitset) clr(i int) {
+	b[i/64] &^= 1 << (uint(i) % 64)
+}
+
+func (b bitset) isset(i int) bool {
+	return b[i/64]&(1<<(uint(i)%64)) != 0
+}
+
+// SetDebug turns ownership tracking on or off at runtime. When on,
+// the pool tracks every checked out object and Put panics on a double
+// free or on an object that doesn't belong to the pool, instead of
+// silently corrupting the free queue.
+//
+// The tracking state is allocated when debugging is first enabled and
+// discarded when it is disabled. Enabling it is O(capacity) and holds
+// the pool lock while the set of currently checked out objects is
+// computed; on a busy pool this stalls all Get/Put callers for that
+// duration. Once enabled, every Get and Put pays for a pointer to slot
+// lookup. Zero sized types can't be tracked.
+//
+// A Put of an object that belongs to a different pool of the same type
+// panics with a message naming both pools, provided the other pool
+// also has debugging enabled.
+func (p *Pool[T]) SetDebug(on bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !on {
+		p.owned = nil
+		p.replain()
+		debugPools.Delete(p)
+		return
+	}
+
+	if p.owned != nil {
+		return
+	}
+	debugPools.Store(p, struct{}{})
+
+	// everything is checked out except what's in the free queue
+	b := newBitset(p.nobjs())
+	for i := 0; i < p.nobjs(); i++ {
+		b.set(i)
+	}
+	p.freeEach(func(x *T) {
+		if i := p.slot(x); i >= 0 {
+			b.clr(i)
+		}
+	})
+	p.owned = b
+	p.replain()
+}
+
+// trackGet records 'x' as checked out; the caller must hold the lock.
+func (p *Pool[T]) trackGet(x *T) {
+	if i := p.slot(x); i >= 0 {
+		p.owned.set(i)
+	}
+}
+
+// trackPut validates and records the return of 'x'; the caller must
+// hold the lock.
+func (p *Pool[T]) trackPut(x *T) {
+	if p.zeroSized() {
+		return
+	}
+
+	i := p.slot(x)
+	if i < 0 {
+		if o := p.owner(x); o != nil {
+			msg := fmt.Sprintf("%s: %p belongs to pool %s", p.label(), x, o.label())
+			panic(msg)
+		}
+		msg := fmt.Sprintf("%s: %p doesn't belong to the pool", p.label(), x)
+		panic(msg)
+	}
+
+	if !p.owned.isset(i) {
+		panic(p.doubleFree(x, i))
+	}
+	p.owned.clr(i)
+}
+
+// owner returns the debug enabled pool of the same type that 'x'
+// belongs to, or nil. Other pools are checked without their lock.
+func (p *Pool[T]) owner(x *T) *Pool[T] {
+	var o *Pool[T]
+	debugPools.Range(func(k, _ any) bool {
+		if q, ok := k.(*Pool[T]); ok && q != p && q.owns(x) {
+			o = q
+			return false
+		}
+		return true
+	})
+	return o
+}
+
+// label returns a short identifier for the pool used in diagnostics
+func (p *Pool[T]) label() string {
+	if p.name != "" {
+		return fmt.Sprintf("%T(%s)", p, p.name)
+	}
+	return fmt.Sprintf("%T(%p)", p, p)
+}
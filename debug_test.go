@@ -0,0 +1,96 @@
+package objpool_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+// mustPanic runs fn and returns the recovered panic value
+func mustPanic(t *testing.T, fn func()) (r any) {
+	t.Helper()
+	defer func() {
+		r = recover()
+		if r == nil {
+			t.Fatalf("expected panic; got none")
+		}
+	}()
+	fn()
+	return nil
+}
+
+func TestDebugDoubleFree(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+
+	// enable with objects already checked out
+	a := o.Get()
+	b := o.Get()
+	o.SetDebug(true)
+
+	o.Put(a)
+	r := mustPanic(t, func() { o.Put(a) })
+	df, ok := r.(*objpool.DoubleFreeError)
+	assert(ok, "wrong panic: %v", r)
+	assert(df.Ptr == any(a), "ptr: exp %p, saw %v", a, df.Ptr)
+	assert(df.Slot >= 0, "slot: saw %d", df.Slot)
+	assert(df.Cap == 4 && df.Avail == 3, "ring state: %+v", df)
+	assert(strings.Contains(df.Error(), "double free"), "wrong message: %s", df)
+
+	var foreign int
+	r = mustPanic(t, func() { o.Put(&foreign) })
+	assert(strings.Contains(r.(string), "doesn't belong"), "wrong panic: %v", r)
+
+	o.Put(b)
+	assert(o.Avail() == 4, "avail: exp 4, saw %d", o.Avail())
+
+	// disabling drops the checks
+	o.SetDebug(false)
+	c := o.Get()
+	o.Put(c)
+	assert(o.Avail() == 4, "avail: exp 4, saw %d", o.Avail())
+}
+
+func TestDropOnOverflow(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](2, objpool.WithDropOnOverflow[int]())
+	a := o.Get()
+	o.Put(a)
+
+	o.Put(a)
+	var foreign int
+	o.Put(&foreign)
+	o.PutN([]*int{a})
+
+	st := o.Stats()
+	assert(st.Overflows == 3, "overflows: exp 3, saw %d", st.Overflows)
+	assert(st.Avail == 2 && st.Puts == 1, "accounting corrupted: %s", st)
+
+	// the ring is intact
+	x, y := o.Get(), o.Get()
+	assert(x != nil && y != nil && x != y, "ring corrupted")
+	assert(o.Get() == nil, "pool grew")
+}
+
+func TestDebugCrossPool(t *testing.T) {
+	assert := newAsserter(t)
+
+	a := objpool.New[int](2, objpool.WithName[int]("alpha"))
+	b := objpool.New[int](2, objpool.WithName[int]("beta"))
+	a.SetDebug(true)
+	b.SetDebug(true)
+	defer a.SetDebug(false)
+	defer b.SetDebug(false)
+
+	x := a.Get()
+	r := mustPanic(t, func() { b.Put(x) })
+	msg := r.(string)
+	assert(strings.Contains(msg, "alpha") && strings.Contains(msg, "beta"),
+		"panic doesn't name both pools: %s", msg)
+
+	a.Put(x)
+	assert(a.Avail() == 2 && b.Avail() == 2, "accounting corrupted")
+}
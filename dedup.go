@@ -0,0 +1,21 @@
+// dedup.go - ignore duplicate Puts of recently returned objects
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// isDup returns true, and logs a warning, if 'x' is among the last
+// p.dedup objects enqueued; the caller must hold the lock.
+func (p *Pool[T]) isDup(x *T) bool {
+	n := min(p.dedup, p.avail)
+	for i, j := 0, p.wr; i < n; i++ {
+		j = p.dec(j)
+		if p.at(j) == x {
+			p.dups += 1
+			p.warnf("%s: ignored duplicate Put of %p", p.label(), x)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package objpool_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestDedupWindow(t *testing.T) {
+	assert := newAsserter(t)
+
+	var r recLogger
+	o := objpool.New[int](4, objpool.WithDedupWindow[int](2))
+	o.SetLogger(&r)
+
+	a, b, c := o.Get(), o.Get(), o.Get()
+	o.Put(a)
+	o.Put(a)
+	assert(o.Avail() == 2, "duplicate enqueued: avail %d", o.Avail())
+
+	o.Put(b)
+	o.Put(a)
+	o.PutN([]*int{b})
+	assert(o.Avail() == 3, "duplicate enqueued: avail %d", o.Avail())
+	assert(o.Stats().Duplicates == 3, "dups: exp 3, saw %d", o.Stats().Duplicates)
+	assert(r.has("duplicate"), "duplicate not logged: %q", r.msgs)
+
+	// the other Put variants check the window too
+	assert(!o.PutIf(b, func(*int) bool { return false }), "putif kept")
+	o.PutPoison(b)
+	assert(o.PutWait(context.Background(), b) == nil, "putwait")
+	assert(o.Avail() == 3, "duplicate enqueued: avail %d", o.Avail())
+	assert(o.Stats().Duplicates == 6, "dups: exp 6, saw %d", o.Stats().Duplicates)
+	assert(o.Stats().Discards == 0, "discarded a duplicate")
+
+	o.Put(c)
+	v := o.GetN(4)
+	seen := make(map[*int]bool)
+	for _, x := range v {
+		assert(!seen[x], "%p handed out twice", x)
+		seen[x] = true
+	}
+}
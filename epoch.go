@@ -0,0 +1,109 @@
+// epoch.go - epoch tracking to support epoch based reclamation
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// The pool maintains a monotonic epoch that advances on every Put.
+// Objects checked out via GetEpoch remember the epoch at which they
+// were handed out; reclamation code that retires a resource at epoch
+// 'e' can reuse it once CanReuse(e) reports that every object handed
+// out at or before 'e' has been returned. This is the grace period
+// used by epoch based memory reclamation schemes.
+//
+// Objects obtained via plain Get, GetBatch etc. don't participate in
+// the grace period computation.
+
+// epochState tracks the checkout epoch of objects obtained via
+// GetEpoch; it's allocated on first use.
+type epochState struct {
+	// checkout epoch of each slot; 0 means untracked
+	slot []uint64
+
+	// number of outstanding objects per checkout epoch
+	out map[uint64]int
+}
+
+// GetEpoch returns a single object from the pool along with the epoch
+// at which it was handed out. It returns nil and 0 if the pool has
+// exhausted its capacity.
+func (p *Pool[T]) GetEpoch() (*T, uint64) {
+	if p.frozen.Load() {
+		if x := p.frozenNext(); x != nil {
+			return x, p.Epoch()
+		}
+		return nil, 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == 0 {
+		p.miss()
+		return nil, 0
+	}
+
+	x := p.get()
+	i := p.slot(x)
+	if i < 0 {
+		return x, p.epoch
+	}
+
+	if p.ep == nil {
+		p.ep = &epochState{
+			slot: make([]uint64, p.nobjs()),
+			out:  make(map[uint64]int),
+		}
+		p.replain()
+	}
+
+	p.ep.slot[i] = p.epoch
+	p.ep.out[p.epoch] += 1
+	return x, p.epoch
+}
+
+// Epoch returns the current epoch of the pool
+func (p *Pool[T]) Epoch() uint64 {
+	p.mu.Lock()
+	e := p.epoch
+	p.mu.Unlock()
+	return e
+}
+
+// CanReuse returns true if every object handed out via GetEpoch at or
+// before epoch 'e' has been returned to the pool.
+func (p *Pool[T]) CanReuse(e uint64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ep == nil {
+		return true
+	}
+
+	for k := range p.ep.out {
+		if k <= e {
+			return false
+		}
+	}
+	return true
+}
+
+// epochPut retires the checkout epoch of 'x'; the caller must hold
+// the lock.
+func (p *Pool[T]) epochPut(x *T) {
+	i := p.slot(x)
+	if i < 0 {
+		return
+	}
+
+	// epochs start at 1; so 0 means the slot isn't tracked
+	if e := p.ep.slot[i]; e > 0 {
+		p.ep.slot[i] = 0
+		if n := p.ep.out[e] - 1; n > 0 {
+			p.ep.out[e] = n
+		} else {
+			delete(p.ep.out, e)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestEpoch(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+
+	a, ea := o.GetEpoch()
+	assert(a != nil, "expected obj; got nil")
+
+	// retire something now; 'a' is still out
+	retired := o.Epoch()
+	assert(retired == ea, "epoch: exp %d, saw %d", ea, retired)
+	assert(!o.CanReuse(retired), "reuse allowed with reader outstanding")
+
+	b, eb := o.GetEpoch()
+	o.Put(a)
+
+	// 'b' was handed out at the retire epoch
+	assert(!o.CanReuse(retired), "reuse allowed with reader outstanding")
+
+	o.Put(b)
+	assert(o.CanReuse(retired), "reuse denied after all readers returned")
+	assert(o.Epoch() > eb, "epoch didn't advance on put")
+
+	// readers that start later don't hold back older epochs
+	c, ec := o.GetEpoch()
+	assert(ec > retired, "epoch: exp > %d, saw %d", retired, ec)
+	assert(o.CanReuse(retired), "newer reader holds back old epoch")
+	assert(!o.CanReuse(ec), "reuse allowed with reader outstanding")
+	o.Put(c)
+}
@@ -0,0 +1,62 @@
+// evict.go - prune free objects in place
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// EvictFreeIf calls 'pred' on every free object and evicts those for
+// which it returns true; it returns the number of evicted objects. The
+// slots of a fixed pool can't be dropped, so an evicted object is
+// refreshed in place: the cleanup hook (if any) releases its resources
+// and it's overwritten with a fresh object (see WithReplacement), ready
+// to be handed out again. Evictions are counted in Stats.Discards.
+//
+// This enables periodic pruning of idle objects (e.g. closing
+// connections that have been idle too long) in one pass without
+// draining the pool. Checked out objects are never passed to 'pred'.
+// The pool lock is held for the duration of the scan; so 'pred' must
+// not call back into the pool. The shared objects of a frozen pool are
+// never evicted.
+func (p *Pool[T]) EvictFreeIf(pred func(*T) bool) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.frozen.Load() {
+		return 0
+	}
+
+	var n int
+	p.freeEach(func(x *T) {
+		if pred(x) {
+			p.refresh(x)
+			n++
+		}
+	})
+	p.discards += uint64(n)
+	return n
+}
+
+// EvictFreeIf calls 'pred' on every free object and drops those for
+// which it returns true; it returns the number of dropped objects.
+// Dropped objects no longer count towards the maximum, so later Gets
+// construct replacements on demand. 'pred' is responsible for releasing
+// the resources of the objects it evicts and must not call back into
+// the pool.
+func (p *LazyElastic[T]) EvictFreeIf(pred func(*T) bool) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keep := p.free[:0]
+	for _, x := range p.free {
+		if !pred(x) {
+			keep = append(keep, x)
+		}
+	}
+
+	n := len(p.free) - len(keep)
+	clear(p.free[len(keep):])
+	p.free = keep
+	p.constructed -= n
+	return n
+}
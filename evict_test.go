@@ -0,0 +1,63 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestEvictFreeIf(t *testing.T) {
+	assert := newAsserter(t)
+
+	var closed int
+	cleanup := func(x *int) {
+		closed++
+	}
+	init := func(x *int) {
+		*x = 1
+	}
+	o := objpool.NewWithInit[int](4, init, objpool.WithCleanup[int](cleanup))
+
+	a := o.Get()
+	b := o.Get()
+	*b = 7
+	o.Put(b)
+
+	// 'a' is checked out and is never evicted
+	n := o.EvictFreeIf(func(x *int) bool { return *x == 1 })
+	assert(n == 2 && closed == 2, "evict: exp 2, saw %d (closed %d)", n, closed)
+	assert(*a == 1 && *b == 7, "wrong objects evicted")
+	assert(o.Avail() == 3, "avail: exp 3, saw %d", o.Avail())
+	assert(o.Stats().Discards == 2, "discards: %d", o.Stats().Discards)
+	o.Put(a)
+
+	var zeros int
+	o.ForEach(func(x *int) {
+		if *x == 0 {
+			zeros++
+		}
+	})
+	assert(zeros == 2, "evicted objects not zeroed: %d", zeros)
+}
+
+func TestLazyElasticEvictFreeIf(t *testing.T) {
+	assert := newAsserter(t)
+
+	var n int
+	o := objpool.NewLazyElastic[int](2, func() *int {
+		n++
+		x := n
+		return &x
+	})
+
+	a, b := o.Get(), o.Get()
+	o.Put(a)
+	o.Put(b)
+
+	k := o.EvictFreeIf(func(x *int) bool { return x == a })
+	assert(k == 1, "evict: exp 1, saw %d", k)
+	assert(o.Constructed() == 1 && o.Avail() == 2, "constructed %d avail %d", o.Constructed(), o.Avail())
+
+	x, y := o.Get(), o.Get()
+	assert(x == b && y != a && *y == 3, "evicted object reused")
+}
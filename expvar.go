@@ -0,0 +1,26 @@
+// expvar.go - publish pool stats via the standard expvar package
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"expvar"
+)
+
+// PublishExpvar publishes the stats of the pool under 'name' via the
+// standard expvar package, so that they show up on /debug/vars without
+// any external metrics library. The variable is an expvar.Func that
+// snapshots Stats every time it's read; it's rendered as JSON with the
+// json tags of Stats (cap, avail, in_use, gets, puts etc.).
+//
+// Like expvar.Publish, PublishExpvar panics if 'name' is already in use;
+// expvar variables can't be removed, so a pool should only be published
+// once and the published pool is kept alive for the life of the
+// program.
+func (p *Pool[T]) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return p.Stats()
+	}))
+}
@@ -0,0 +1,71 @@
+// factory.go - pools of individually allocated objects
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FromFactory creates a pool of 'sz' objects made by calling 'factory'
+// 'sz' times at construction time. Unlike New, the objects aren't laid
+// out in a contiguous backing array; this is for objects that must be
+// allocated individually, e.g. because they come from a constructor of
+// another package. The pool manages the factory-made objects with the
+// usual ring discipline and Reset re-enqueues the same objects; they
+// are never re-created.
+//
+// The factory must return distinct, non-nil objects; FromFactory panics
+// otherwise. Options that need a backing array (WithCompact) are
+// ignored and SwapBacking always fails.
+func FromFactory[T any](sz int, factory func() *T, opts ...Option[T]) *Pool[T] {
+	objs := make([]*T, sz)
+	for i := range objs {
+		objs[i] = factory()
+	}
+
+	o, err := fromObjs(objs, opts...)
+	if err != nil {
+		panic(fmt.Sprintf("%s: factory %s", o.label(), err))
+	}
+	return o
+}
+
+// fromObjs creates a pool that manages the distinct, non-nil objects in
+// 'objs'; it returns an error (and the partially built pool for the
+// sake of its label) otherwise.
+func fromObjs[T any](objs []*T, opts ...Option[T]) (*Pool[T], error) {
+	o := &Pool[T]{
+		avail:  len(objs),
+		size:   len(objs),
+		epoch:  1,
+		objs:   objs,
+		objIdx: make(map[*T]int, len(objs)),
+	}
+
+	o.cond = sync.NewCond(&o.mu)
+	o.room = sync.NewCond(&o.mu)
+
+	for _, fn := range opts {
+		fn(o)
+	}
+	o.compact = false
+
+	for i, x := range objs {
+		if x == nil {
+			return o, fmt.Errorf("returned nil")
+		}
+		if _, ok := o.objIdx[x]; ok {
+			return o, fmt.Errorf("returned %p twice", x)
+		}
+		o.objIdx[x] = i
+	}
+
+	o.sideTables()
+	o.fill()
+	o.replain()
+	return o, nil
+}
@@ -0,0 +1,62 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+type conn struct {
+	id int
+}
+
+func TestFromFactory(t *testing.T) {
+	assert := newAsserter(t)
+
+	made := make(map[*conn]bool)
+	n := 0
+	factory := func() *conn {
+		n++
+		c := &conn{id: n}
+		made[c] = true
+		return c
+	}
+
+	o := objpool.FromFactory[conn](3, factory)
+	assert(n == 3 && o.Avail() == 3, "factory: exp 3 calls, saw %d", n)
+
+	v := o.GetN(3)
+	for _, c := range v {
+		assert(made[c], "%p not made by the factory", c)
+	}
+	assert(o.Get() == nil, "pool not exhausted")
+
+	for _, c := range v {
+		o.Put(c)
+	}
+	assert(o.Reset() == nil, "reset")
+	assert(n == 3, "reset re-created objects")
+
+	c := o.Get()
+	assert(c == v[0], "reset didn't re-enqueue in factory order")
+
+	// slot based features work without a backing array
+	o.SetDebug(true)
+	defer o.SetDebug(false)
+
+	o.Put(c)
+	r := mustPanic(t, func() { o.Put(c) })
+	_, ok := r.(*objpool.DoubleFreeError)
+	assert(ok, "wrong panic: %v", r)
+
+	mustPanic(t, func() { o.Put(&conn{}) })
+	assert(objpool.PutTo(&conn{}, o) == false, "foreign object owned")
+
+	_, err := o.SwapBacking(make([]conn, 3))
+	assert(err != nil, "swapped backing of a factory pool")
+
+	same := &conn{}
+	mustPanic(t, func() {
+		objpool.FromFactory[conn](2, func() *conn { return same })
+	})
+}
@@ -0,0 +1,102 @@
+// fallback.go - shared allocator for exhausted pools
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"sync"
+)
+
+// fallbackKey is the key of the fallback allocator of type T in
+// fallbacks; each instantiation is a distinct key.
+type fallbackKey[T any] struct{}
+
+// fallbacks maps fallbackKey[T] to the func() *T set via
+// SetFallbackAllocator
+var fallbacks sync.Map
+
+// SetFallbackAllocator sets the allocator that GetOrFallback uses, for
+// every pool of type T, once the pool is exhausted; a nil 'fn' removes
+// it. This gives many pools of the same type a uniform overflow
+// strategy without configuring each as an elastic pool.
+func SetFallbackAllocator[T any](fn func() *T) {
+	if fn == nil {
+		fallbacks.Delete(fallbackKey[T]{})
+		return
+	}
+	fallbacks.Store(fallbackKey[T]{}, fn)
+}
+
+// GetOrFallback is like Get but, if the pool is exhausted, returns an
+// object of the fallback allocator of T set via SetFallbackAllocator;
+// it returns nil only if the pool is empty and there is no allocator.
+//
+// A fallback object doesn't belong to the pool and is never enqueued:
+// once a pool has handed out a fallback object, every Put variant checks
+// ownership (see Owns) and drops objects that don't belong to the pool,
+// leaving them to the garbage collector. So fallback objects can't
+// corrupt the ring, but from then on a misdirected Put of another
+// pool's object is silently dropped too, rather than being enqueued or
+// reported as a double free. The first fallback of a pool logs a
+// warning to that effect. A queue created by NewQueue owns none of its
+// objects; fallback objects are enqueued like any other object.
+//
+// Objects of zero sized types can't be told apart, so such a pool
+// counts the fallback objects it handed out instead: a Put that finds
+// the free queue full while some are out is taken to return one of
+// them and is dropped. Fallback allocations are counted in
+// Stats.Fallbacks.
+func (p *Pool[T]) GetOrFallback() *T {
+	if x := p.Get(); x != nil {
+		return x
+	}
+
+	fn, ok := fallbacks.Load(fallbackKey[T]{})
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case p.queue:
+	case p.zeroSized():
+		p.mu.Lock()
+		p.zeroFalls += 1
+		p.mu.Unlock()
+	case p.fellBack.CompareAndSwap(false, true):
+		p.slow.Store(true)
+		p.warnf("%s: fell back to the fallback allocator; Puts of objects that don't belong to the pool are dropped from now on",
+			p.label())
+	}
+	p.fallbacks.Add(1)
+	return fn.(func() *T)()
+}
+
+// fallback returns true if 'x' must be dropped by Put because it may
+// be an object of the fallback allocator.
+func (p *Pool[T]) fallback(x *T) bool {
+	return p.fellBack.Load() && !p.owns(x)
+}
+
+// dropFallback returns true if a Put that found the free queue full
+// returns a fallback object of a zero sized type and must be dropped;
+// the caller must hold the lock.
+func (p *Pool[T]) dropFallback() bool {
+	if p.zeroFalls == 0 {
+		return false
+	}
+	p.zeroFalls -= 1
+	return true
+}
+
+// ownedOnly returns the objects of 'v' that belong to the pool
+func (p *Pool[T]) ownedOnly(v []*T) []*T {
+	w := make([]*T, 0, len(v))
+	for _, x := range v {
+		if p.owns(x) {
+			w = append(w, x)
+		}
+	}
+	return w
+}
@@ -0,0 +1,78 @@
+package objpool_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestFallbackAllocator(t *testing.T) {
+	assert := newAsserter(t)
+
+	type buf struct {
+		fallback bool
+	}
+
+	o := objpool.New[buf](1)
+	a := o.GetOrFallback()
+	assert(a != nil && !a.fallback, "pool obj: %+v", a)
+	assert(o.GetOrFallback() == nil, "fallback without allocator")
+
+	objpool.SetFallbackAllocator(func() *buf {
+		return &buf{fallback: true}
+	})
+	defer objpool.SetFallbackAllocator[buf](nil)
+
+	b := o.GetOrFallback()
+	c := o.GetOrFallback()
+	assert(b != nil && b.fallback && c.fallback, "fallback obj: %+v", b)
+
+	// fallback objects are dropped, pool objects enqueued
+	o.Put(b)
+	assert(o.Avail() == 0, "fallback obj enqueued")
+	o.PutN([]*buf{c, a})
+	assert(o.Avail() == 1, "avail: exp 1, saw %d", o.Avail())
+	o.PutPoison(b)
+	assert(!o.PutIf(b, func(*buf) bool { return true }), "fallback obj kept")
+	assert(o.PutWait(context.Background(), b) == nil, "putwait of fallback obj")
+	assert(o.Avail() == 1, "fallback obj enqueued")
+	assert(o.Stats().Fallbacks == 2, "fallbacks: exp 2, saw %d", o.Stats().Fallbacks)
+
+	// the allocator is per type
+	n := objpool.New[int](0)
+	assert(n.GetOrFallback() == nil, "fallback of another type")
+}
+
+func TestFallbackZeroSized(t *testing.T) {
+	assert := newAsserter(t)
+
+	type token struct{}
+
+	objpool.SetFallbackAllocator(func() *token {
+		return &token{}
+	})
+	defer objpool.SetFallbackAllocator[token](nil)
+
+	o := objpool.New[token](2)
+	a := o.GetOrFallback()
+	b := o.GetOrFallback()
+	c := o.GetOrFallback()
+	assert(a != nil && b != nil && c != nil, "get: %v %v %v", a, b, c)
+	assert(o.Stats().Fallbacks == 1, "fallbacks: %d", o.Stats().Fallbacks)
+
+	// the extra Put returns the fallback object and is dropped
+	o.Put(c)
+	o.Put(b)
+	o.Put(a)
+	assert(o.Avail() == 2, "avail: exp 2, saw %d", o.Avail())
+	assert(o.Stats().Overflows == 0, "overflows: %d", o.Stats().Overflows)
+
+	// and the pool keeps taking its objects back
+	for i := 0; i < 4; i++ {
+		x := o.Get()
+		assert(x != nil, "get %d from a drained pool", i)
+		o.Put(x)
+	}
+	assert(o.Avail() == 2, "avail: exp 2, saw %d", o.Avail())
+}
@@ -0,0 +1,51 @@
+// fork.go - seed an independent pool from the objects of another
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+)
+
+// Fork creates a new, independent pool of the same capacity whose
+// objects are copies of the objects of 'p'; 'p' itself is left
+// untouched. This lets a baseline pool of configured objects seed any
+// number of worker pools. 'copyFn' copies 'src' into 'dst' and must
+// deep copy whatever T refers to (slices, maps, pointers) that the
+// forks mustn't share; a nil 'copyFn' makes shallow copies. The fork
+// has a backing array of its own, even if 'p' was created by
+// FromFactory, and inherits the name and hooks of 'p'.
+//
+// A consistent fork point needs every object to be in the pool; Fork
+// returns ErrInUse if any is checked out. The lock of 'p' is held while
+// the objects are copied, so 'copyFn' must not call back into it.
+func (p *Pool[T]) Fork(copyFn func(dst, src *T)) (*Pool[T], error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.queue:
+		return nil, fmt.Errorf("objpool: can't fork a queue")
+	case p.avail != p.size:
+		return nil, ErrInUse
+	}
+
+	arr := make([]T, p.nobjs())
+	for i := range arr {
+		if copyFn != nil {
+			copyFn(&arr[i], p.obj(i))
+		} else {
+			arr[i] = *p.obj(i)
+		}
+	}
+
+	o := newPool(arr, WithName[T](p.name))
+	o.reset = p.reset
+	o.cleanup = p.cleanup
+	o.init = p.init
+	o.sizer = p.sizer
+	o.replace = p.replace
+	return o, nil
+}
@@ -0,0 +1,51 @@
+package objpool_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestFork(t *testing.T) {
+	assert := newAsserter(t)
+
+	type conf struct {
+		id   int
+		tags []string
+	}
+
+	var n int
+	base := objpool.NewWithInit[conf](3, func(c *conf) {
+		n++
+		c.id = n
+		c.tags = []string{"base"}
+	}, objpool.WithName[conf]("base"))
+
+	deep := func(dst, src *conf) {
+		*dst = *src
+		dst.tags = append([]string(nil), src.tags...)
+	}
+
+	f, err := base.Fork(deep)
+	assert(err == nil, "fork: %v", err)
+	assert(f.Cap() == 3 && f.Avail() == 3, "fork: %s", f)
+
+	x := f.Get()
+	assert(x.id == 1 && x.tags[0] == "base", "fork obj: %+v", *x)
+	assert(!base.Owns(x), "fork shares objects")
+	x.tags[0] = "worker"
+
+	y := base.Get()
+	assert(y.tags[0] == "base", "deep copy shared: %+v", *y)
+
+	_, err = base.Fork(deep)
+	assert(errors.Is(err, objpool.ErrInUse), "fork of busy pool: %v", err)
+	base.Put(y)
+
+	// shallow copies by default
+	s, err := base.Fork(nil)
+	assert(err == nil, "fork: %v", err)
+	z := s.Get()
+	assert(z.id == 1 && &z.tags[0] == &y.tags[0], "shallow copy: %+v", *z)
+}
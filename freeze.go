@@ -0,0 +1,69 @@
+// freeze.go - read-only pools of immutable templates
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+)
+
+// Freeze turns an idle, warmed up pool into a read-only pool of
+// immutable prototypes. After Freeze, the set of objects and their
+// contents never change, so there is nothing left for the lock to
+// protect:
+//
+//   - Get hands out a shared pointer to the next object in rotation
+//     without taking the lock; the object is never checked out and
+//     callers must treat it as read-only. The other Get variants,
+//     including the batch and blocking ones, hand out shared objects
+//     the same way and never block.
+//   - GetCopy returns a copy of the next object, also without the lock.
+//   - Put and its variants are no-ops; the reset hook is not run so it
+//     can't write to an object other goroutines may be reading, and
+//     PutIf and PutPoison don't rebuild objects. ReclaimTag reclaims
+//     nothing.
+//
+// This is only safe because nothing mutates the backing array after
+// Freeze: Reset and SwapBacking return ErrFrozen, and callers must not
+// use Replace or ForEach to modify the objects. The stats counters are
+// not updated by a frozen pool (so its readers don't contend on them).
+//
+// Freeze returns ErrInUse if any object is checked out; freezing a
+// frozen pool is a no-op. A pool can't be thawed.
+func (p *Pool[T]) Freeze() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.queue {
+		return fmt.Errorf("objpool: can't freeze a queue")
+	}
+	if p.avail != p.size {
+		return ErrInUse
+	}
+
+	// the atomic store publishes the warmed up contents to the lock
+	// free readers that observe 'frozen'.
+	p.frozen.Store(true)
+	p.slow.Store(true)
+	p.replain()
+	p.debugf("frozen")
+	return nil
+}
+
+// Frozen returns true if the pool was frozen via Freeze
+func (p *Pool[T]) Frozen() bool {
+	return p.frozen.Load()
+}
+
+// frozenNext returns the next object of a frozen pool in rotation or
+// nil if the pool is empty.
+func (p *Pool[T]) frozenNext() *T {
+	if p.size == 0 {
+		return nil
+	}
+
+	i := p.fnext.Add(1) - 1
+	return p.obj(int(i % uint64(p.size)))
+}
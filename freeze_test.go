@@ -0,0 +1,138 @@
+package objpool_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestFreeze(t *testing.T) {
+	assert := newAsserter(t)
+
+	init := func(x *int) {
+		*x = 42
+	}
+	o := objpool.NewWithInit[int](3, init)
+
+	a := o.Get()
+	err := o.Freeze()
+	assert(err == objpool.ErrInUse, "freeze busy pool: %v", err)
+	o.Put(a)
+
+	err = o.Freeze()
+	assert(err == nil, "freeze: %v", err)
+	assert(o.Frozen(), "not frozen")
+	err = o.Freeze()
+	assert(err == nil, "refreeze: %v", err)
+
+	// Get rotates through shared objects and Put is a no-op
+	seen := make(map[*int]int)
+	for i := 0; i < 6; i++ {
+		x := o.Get()
+		assert(x != nil && *x == 42, "get %d: %v", i, x)
+		seen[x] += 1
+		o.Put(x)
+	}
+	assert(len(seen) == 3, "rotation: exp 3 objects, saw %d", len(seen))
+	assert(o.Avail() == 3, "avail: exp 3, saw %d", o.Avail())
+
+	v, ok := o.GetCopy()
+	assert(ok && v == 42, "getcopy: %v %d", ok, v)
+
+	assert(o.Reset() == objpool.ErrFrozen, "reset frozen pool")
+	_, err = o.SwapBacking(make([]int, 3))
+	assert(err == objpool.ErrFrozen, "swap frozen pool: %v", err)
+
+	q := objpool.NewQueue[int](2)
+	assert(q.Freeze() != nil, "froze a queue")
+}
+
+func TestFreezeVariants(t *testing.T) {
+	assert := newAsserter(t)
+
+	var resets int
+	reset := func(x *int) error {
+		resets += 1
+		*x = 0
+		return nil
+	}
+	init := func(x *int) {
+		*x = 42
+	}
+	o := objpool.NewWithInit[int](3, init, objpool.WithReset(reset))
+	assert(o.Freeze() == nil, "freeze")
+
+	shared := func(what string, v ...*int) {
+		t.Helper()
+		for _, x := range v {
+			assert(x != nil && *x == 42, "%s: %v", what, x)
+		}
+	}
+
+	// every Get variant hands out shared objects without blocking
+	v := o.GetN(5)
+	assert(len(v) == 5, "getn: %d", len(v))
+	shared("getn", v...)
+
+	shared("gettimeout", o.GetTimeout(time.Second))
+	x, err := o.GetContext(context.Background())
+	assert(err == nil, "getcontext: %v", err)
+	shared("getcontext", x)
+
+	b := o.GetBatch(context.Background(), 4, time.Second)
+	assert(len(b) == 4, "getbatch: %d", len(b))
+	shared("getbatch", b...)
+
+	x, i := o.GetTagged("req")
+	assert(i == -1, "gettagged slot: %d", i)
+	shared("gettagged", x)
+	assert(len(o.DumpTags()) == 0, "frozen pool tagged: %v", o.DumpTags())
+
+	x, e := o.GetEpoch()
+	assert(e == o.Epoch(), "getepoch: %d", e)
+	shared("getepoch", x)
+
+	// and every Put variant leaves them alone
+	o.PutN(v)
+	assert(o.PutWait(context.Background(), x) == nil, "putwait")
+	assert(o.PutIf(x, func(*int) bool { return false }), "putif")
+	o.PutPoison(x)
+	assert(o.ReclaimTag("req") == 0, "reclaimtag")
+	assert(o.EvictFreeIf(func(*int) bool { return true }) == 0, "evicted shared objects")
+
+	for j := 0; j < 3; j++ {
+		shared("after put", o.Get())
+	}
+	assert(resets == 0, "reset ran %d times", resets)
+	assert(o.Avail() == 3, "avail: %d", o.Avail())
+
+	st := o.Stats()
+	assert(st.Gets == 0 && st.Puts == 0, "counters: %d %d", st.Gets, st.Puts)
+	assert(st.Discards == 0 && st.PoisonRebuilds == 0, "rebuilds: %d %d",
+		st.Discards, st.PoisonRebuilds)
+}
+
+func TestFreezeConcurrent(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+	assert(o.Freeze() == nil, "freeze")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				x := o.Get()
+				_ = *x
+				o.Put(x)
+				o.GetCopy()
+			}
+		}()
+	}
+	wg.Wait()
+}
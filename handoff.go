@@ -0,0 +1,147 @@
+// handoff.go - hand a warm pool over to a successor process
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// stateMagic identifies the encoding of ExportState
+const stateMagic = "OPS1"
+
+// NewFromSlice creates a pool whose backing array is 'arr' rather than
+// one allocated by the pool; its capacity is len(arr). The pool takes
+// ownership of 'arr' and the objects in it are handed out as is. This
+// lets a pool live in memory the caller manages, e.g. a shared memory
+// mapping that survives a restart (see ExportState).
+func NewFromSlice[T any](arr []T, opts ...Option[T]) *Pool[T] {
+	return newPool(arr, opts...)
+}
+
+// ExportState serializes the ring indices and the free set of the pool
+// so that a successor process can resume the pool via ImportState
+// without reconstructing its objects. Objects that are checked out when
+// the state is exported are checked out in the successor as well; a
+// pool should therefore be drained before the handoff, since nothing in
+// the successor will Put them back.
+//
+// The state only records slot indices, not the objects themselves:
+// the objects survive the handoff only if the backing array does, i.e.
+// if the pool was created by NewFromSlice over a shared mapping that
+// the successor maps as well.
+func (p *Pool[T]) ExportState() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.queue || p.objs != nil {
+		return nil, fmt.Errorf("objpool: pool has no backing array")
+	}
+
+	b := make([]byte, 0, len(stateMagic)+(4+p.avail)*binary.MaxVarintLen32)
+	b = append(b, stateMagic...)
+	b = binary.AppendUvarint(b, uint64(p.size))
+	b = binary.AppendUvarint(b, uint64(p.rd))
+	b = binary.AppendUvarint(b, uint64(p.avail))
+	p.freeEach(func(x *T) {
+		b = binary.AppendUvarint(b, uint64(p.slot(x)))
+	})
+	return b, nil
+}
+
+// ImportState restores the ring indices and the free set exported via
+// ExportState by a predecessor. The pool must be idle, as it is right
+// after construction, and have the same capacity as the exported pool;
+// ImportState returns ErrInUse or ErrBadState without changing anything
+// otherwise. Objects that aren't in the imported free set are
+// considered checked out.
+//
+// Resuming a pool this way is only safe under strict constraints that
+// the pool can't verify: the backing array passed to NewFromSlice must
+// be the same memory as the predecessor's (e.g. the same shared
+// mapping), T must have an identical layout in both programs, and T
+// must not hold pointers into the heap of the predecessor - Go pointers,
+// slices, maps, strings and interfaces are all meaningless in another
+// process.
+func (p *Pool[T]) ImportState(b []byte) error {
+	free, rd, err := p.decodeState(b)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.queue || p.objs != nil:
+		return fmt.Errorf("objpool: pool has no backing array")
+	case p.frozen.Load():
+		return ErrFrozen
+	case p.avail != p.size:
+		return ErrInUse
+	}
+
+	p.rd = rd
+	p.avail = len(free)
+	p.wr = rd
+	for _, i := range free {
+		p.setAt(p.wr, &p.arr[i])
+		p.wr = p.inc(p.wr)
+	}
+
+	if p.owned != nil {
+		p.owned = newBitset(p.size)
+		for i := 0; i < p.size; i++ {
+			p.owned.set(i)
+		}
+		for _, i := range free {
+			p.owned.clr(i)
+		}
+	}
+	p.ep = nil
+	p.replain()
+	p.debugf("imported state; %d of %d free", p.avail, p.size)
+	return nil
+}
+
+// decodeState validates and decodes the output of ExportState
+func (p *Pool[T]) decodeState(b []byte) ([]int, int, error) {
+	if len(b) < len(stateMagic) || string(b[:len(stateMagic)]) != stateMagic {
+		return nil, 0, ErrBadState
+	}
+	b = b[len(stateMagic):]
+
+	next := func() (int, bool) {
+		v, n := binary.Uvarint(b)
+		if n <= 0 || v > uint64(p.size) {
+			return 0, false
+		}
+		b = b[n:]
+		return int(v), true
+	}
+
+	sz, ok1 := next()
+	rd, ok2 := next()
+	avail, ok3 := next()
+	if !(ok1 && ok2 && ok3) || sz != p.size || (rd >= sz && sz > 0) {
+		return nil, 0, ErrBadState
+	}
+
+	seen := newBitset(sz)
+	free := make([]int, avail)
+	for k := range free {
+		i, ok := next()
+		if !ok || i >= sz || seen.isset(i) {
+			return nil, 0, ErrBadState
+		}
+		seen.set(i)
+		free[k] = i
+	}
+	if len(b) != 0 {
+		return nil, 0, ErrBadState
+	}
+	return free, rd, nil
+}
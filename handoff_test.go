@@ -0,0 +1,54 @@
+package objpool_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestHandoff(t *testing.T) {
+	assert := newAsserter(t)
+
+	arr := make([]int, 5)
+	for i := range arr {
+		arr[i] = i * 10
+	}
+
+	o := objpool.NewFromSlice(arr)
+	assert(o.Cap() == 5, "cap: exp 5, saw %d", o.Cap())
+
+	a, b := o.Get(), o.Get()
+	assert(a == &arr[0] && b == &arr[1], "objects not from slice")
+	o.Put(a)
+
+	st, err := o.ExportState()
+	assert(err == nil, "export: %v", err)
+	before := o.Snapshot()
+
+	// the successor maps the same memory
+	n := objpool.NewFromSlice(arr, objpool.WithCompact[int]())
+	err = n.ImportState(st)
+	assert(err == nil, "import: %v", err)
+	after := n.Snapshot()
+	assert(before.String() == after.String(), "state mismatch:\n%s\n%s", before, after)
+
+	x := n.NextFree()
+	assert(x == &arr[2] && *x == 20, "next free: %p", x)
+	n.Put(b)
+	assert(n.Avail() == 5, "avail: exp 5, saw %d", n.Avail())
+
+	// the successor is no longer idle
+	n.Get()
+	err = n.ImportState(st)
+	assert(errors.Is(err, objpool.ErrInUse), "import into busy pool: %v", err)
+
+	m := objpool.New[int](4)
+	err = m.ImportState(st)
+	assert(errors.Is(err, objpool.ErrBadState), "import of wrong cap: %v", err)
+
+	m = objpool.New[int](5)
+	err = m.ImportState(st[:len(st)-1])
+	assert(errors.Is(err, objpool.ErrBadState), "import of short state: %v", err)
+	assert(m.Avail() == 5, "failed import changed pool")
+}
@@ -0,0 +1,37 @@
+// hist.go - distribution of concurrent usage
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// histBuckets is the number of buckets of UsageHistogram
+const histBuckets = 10
+
+// UsageHistogram returns how often the pool was observed with a given
+// fraction of its capacity checked out, sampled on every Get and Put:
+// bucket i counts the samples where between i*10% and (i+1)*10% of Cap
+// was in use, with a fully checked out pool counted in the last bucket.
+// Unlike a high-water mark, the distribution shows how the pool is
+// typically used ("usually 20% in use, rarely 90%"), which is what
+// right-sizing needs. Reset clears the histogram. UsageHistogram
+// returns nil if the pool wasn't created with WithUsageHistogram.
+func (p *Pool[T]) UsageHistogram() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.hist == nil {
+		return nil
+	}
+
+	v := make([]int, len(p.hist))
+	copy(v, p.hist)
+	return v
+}
+
+// sample records the current in-use count; the caller must hold the
+// lock.
+func (p *Pool[T]) sample() {
+	i := (p.size - p.avail) * histBuckets / p.size
+	p.hist[min(i, histBuckets-1)] += 1
+}
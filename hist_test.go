@@ -0,0 +1,38 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestUsageHistogram(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](10, objpool.WithUsageHistogram[int]())
+
+	// the Gets sample 1..10 in use, the Puts 9..0
+	v := o.GetN(10)
+	for _, x := range v {
+		o.Put(x)
+	}
+
+	h := o.UsageHistogram()
+	exp := []int{1, 2, 2, 2, 2, 2, 2, 2, 2, 3}
+	assert(len(h) == len(exp), "buckets: %d", len(h))
+	for i := range exp {
+		assert(h[i] == exp[i], "bucket %d: exp %d, saw %d; %v", i, exp[i], h[i], h)
+	}
+
+	// the caller's copy is independent
+	h[0] = 100
+	assert(o.UsageHistogram()[0] == 1, "histogram aliased")
+
+	assert(o.Reset() == nil, "reset")
+	for i, n := range o.UsageHistogram() {
+		assert(n == 0, "bucket %d not cleared: %d", i, n)
+	}
+
+	p := objpool.New[int](1)
+	assert(p.UsageHistogram() == nil, "histogram without the option")
+}
@@ -0,0 +1,66 @@
+// idle.go - report the longest idle free object
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"time"
+)
+
+// OldestIdle returns the free object that has been idle the longest,
+// i.e. whose last Put is the oldest, and for how long it has been idle;
+// objects that were never returned count as idle since the pool was
+// created. It's meant for LRU style decisions such as health checking
+// or pruning the stalest connection first. OldestIdle returns nil if
+// the pool is empty or wasn't created with WithIdleTracking.
+//
+// OldestIdle scans the free queue under the pool lock, which costs
+// O(Avail) time and holds off Get and Put for the duration of the scan;
+// call it sparingly on large pools. In the common case the free queue
+// is in Put order and the head of the queue is the oldest object, but
+// GetCopy and Reset reorder the queue, so the scan doesn't rely on it.
+func (p *Pool[T]) OldestIdle() (*T, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.idle == nil {
+		return nil, 0
+	}
+
+	var old *T
+	var t0 time.Time
+	p.freeEach(func(x *T) {
+		i := p.slot(x)
+		if i < 0 {
+			return
+		}
+		if old == nil || p.idle[i].Before(t0) {
+			old, t0 = x, p.idle[i]
+		}
+	})
+
+	if old == nil {
+		return nil, 0
+	}
+	return old, now().Sub(t0)
+}
+
+// stamp records the time of the Put of 'x'; the caller must hold the
+// lock.
+func (p *Pool[T]) stamp(x *T) {
+	if i := p.slot(x); i >= 0 {
+		p.idle[i] = now()
+	}
+}
+
+// newIdle returns the idle times of 'n' slots that are idle as of now
+func newIdle(n int) []time.Time {
+	t := now()
+	v := make([]time.Time, n)
+	for i := range v {
+		v[i] = t
+	}
+	return v
+}
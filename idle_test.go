@@ -0,0 +1,98 @@
+package objpool_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestOldestIdle(t *testing.T) {
+	assert := newAsserter(t)
+
+	t0 := time.Now()
+	clk := t0
+	objpool.SetClock(func() time.Time {
+		return clk
+	})
+	defer objpool.SetClock(nil)
+
+	o := objpool.New[int](3, objpool.WithIdleTracking[int]())
+	a, b, c := o.Get(), o.Get(), o.Get()
+
+	x, d := o.OldestIdle()
+	assert(x == nil && d == 0, "oldest of an empty pool: %p", x)
+
+	clk = t0.Add(1 * time.Second)
+	o.Put(b)
+	clk = t0.Add(2 * time.Second)
+	o.Put(a)
+	clk = t0.Add(3 * time.Second)
+	o.Put(c)
+
+	clk = t0.Add(10 * time.Second)
+	x, d = o.OldestIdle()
+	assert(x == b && d == 9*time.Second, "oldest: exp %p 9s, saw %p %s", b, x, d)
+
+	// rotating the queue doesn't confuse the scan
+	o.GetCopy()
+	x, _ = o.OldestIdle()
+	assert(x == b, "oldest after rotation: exp %p, saw %p", b, x)
+
+	// the oldest object is handed out; the next oldest is reported
+	for o.NextFree() != b {
+		o.GetCopy()
+	}
+	y := o.Get()
+	x, d = o.OldestIdle()
+	assert(x == a && d == 8*time.Second, "oldest: exp %p 8s, saw %p %s", a, x, d)
+	o.Put(y)
+
+	p := objpool.New[int](2)
+	x, _ = p.OldestIdle()
+	assert(x == nil, "untracked pool reported %p", x)
+}
+
+func TestLRU(t *testing.T) {
+	assert := newAsserter(t)
+
+	t0 := time.Now()
+	clk := t0
+	objpool.SetClock(func() time.Time {
+		return clk
+	})
+	defer objpool.SetClock(nil)
+
+	o := objpool.New[int](4, objpool.WithLRU[int](), objpool.WithIdleTracking[int]())
+	v := o.GetN(4)
+	for i, x := range v {
+		clk = t0.Add(time.Duration(i) * time.Second)
+		o.Put(x)
+	}
+
+	// the hot set is the most recently returned
+	assert(o.NextFree() == v[3], "next free: exp %p, saw %p", v[3], o.NextFree())
+	for i := 0; i < 10; i++ {
+		x := o.Get()
+		assert(x == v[3], "get %d: exp %p, saw %p", i, v[3], x)
+		clk = clk.Add(time.Second)
+		o.Put(x)
+	}
+
+	// the cold objects age out
+	clk = clk.Add(time.Minute)
+	x, d := o.OldestIdle()
+	assert(x == v[0], "oldest: exp %p, saw %p", v[0], x)
+
+	n := o.EvictFreeIf(func(y *int) bool {
+		return y != v[3]
+	})
+	assert(n == 3 && d > time.Minute, "evicted %d", n)
+
+	w := o.GetN(4)
+	exp := []*int{v[3], v[2], v[1], v[0]}
+	for i := range exp {
+		assert(w[i] == exp[i], "get %d: exp %p, saw %p", i, exp[i], w[i])
+	}
+	assert(o.Get() == nil, "get from empty pool")
+}
@@ -0,0 +1,32 @@
+// iface.go - common interface of the pool variants
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// Interface is the minimal set of methods that every pool of pointers
+// in this package provides: Pool, LockFree, LazyElastic, Lazy, NUMA and
+// Ordered. Code written against it can switch between implementations,
+// e.g. to use a fake in tests. The concrete types don't depend on it;
+// calls made through the concrete type are direct and only calls made
+// through the interface pay for dynamic dispatch.
+type Interface[T any] interface {
+	// Get returns a free object or nil if there is none
+	Get() *T
+
+	// Put returns an object obtained from Get
+	Put(x *T)
+
+	// Avail returns the number of objects that Get can hand out
+	Avail() int
+}
+
+var (
+	_ Interface[int] = (*Pool[int])(nil)
+	_ Interface[int] = (*LockFree[int])(nil)
+	_ Interface[int] = (*LazyElastic[int])(nil)
+	_ Interface[int] = (*Lazy[int])(nil)
+	_ Interface[int] = (*NUMA[int])(nil)
+	_ Interface[int] = (*Ordered[int])(nil)
+)
@@ -0,0 +1,29 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestInlinePool(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.NewInlinePool[[2]int](2)
+	assert(o.Avail() == 2 && o.Cap() == 2, "avail: %d", o.Avail())
+
+	a, ok := o.Get()
+	assert(ok && a == [2]int{}, "get: %v %v", ok, a)
+	b, _ := o.Get()
+	_, ok = o.Get()
+	assert(!ok, "get from empty pool")
+
+	a[0] = 7
+	o.Put(a)
+	o.Put(b)
+	mustPanic(t, func() { o.Put(b) })
+
+	// values are kept by Put and come back in FIFO order
+	x, _ := o.Get()
+	assert(x == [2]int{7, 0}, "exp put value, saw %v", x)
+}
@@ -0,0 +1,139 @@
+// keyed.go - per-caller limits on checked out objects
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Keyed limits the number of objects that each logical caller, e.g. a
+// tenant, can hold from a pool at the same time, so that no caller can
+// monopolize the pool. Callers are identified by a comparable key that
+// they pass to every Get and Put. Once a key holds 'limit' objects,
+// further Gets for that key fail (GetKeyed) or wait (GetKeyedContext)
+// until it returns some; other keys are unaffected. The pool itself
+// remains usable directly, outside of any limit.
+type Keyed[T any] struct {
+	p     *Pool[T]
+	limit int
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	waiters int
+	held    map[any]int
+}
+
+// NewKeyed returns a view of 'p' that allows every key to hold at most
+// 'limit' objects at a time.
+func NewKeyed[T any](p *Pool[T], limit int) *Keyed[T] {
+	if limit <= 0 {
+		panic(fmt.Sprintf("objpool: invalid per-key limit %d", limit))
+	}
+
+	k := &Keyed[T]{
+		p:     p,
+		limit: limit,
+		held:  make(map[any]int),
+	}
+	k.cond = sync.NewCond(&k.mu)
+	return k
+}
+
+// GetKeyed returns an object on behalf of 'key'; it returns nil if
+// 'key' already holds its limit of objects or if the pool is exhausted.
+func (k *Keyed[T]) GetKeyed(key any) *T {
+	k.mu.Lock()
+	if k.held[key] >= k.limit {
+		k.mu.Unlock()
+		return nil
+	}
+	k.held[key] += 1
+	k.mu.Unlock()
+
+	x := k.p.Get()
+	if x == nil {
+		k.release(key)
+	}
+	return x
+}
+
+// GetKeyedContext is like GetKeyed but blocks until 'key' is below its
+// limit and the pool has a free object, or the context is done; it
+// returns the context's error in the latter case.
+func (k *Keyed[T]) GetKeyedContext(ctx context.Context, key any) (*T, error) {
+	k.mu.Lock()
+	if k.held[key] >= k.limit {
+		stop := context.AfterFunc(ctx, func() {
+			k.mu.Lock()
+			k.cond.Broadcast()
+			k.mu.Unlock()
+		})
+		defer stop()
+
+		for k.held[key] >= k.limit {
+			if err := ctx.Err(); err != nil {
+				k.mu.Unlock()
+				return nil, err
+			}
+			k.waiters += 1
+			k.cond.Wait()
+			k.waiters -= 1
+		}
+	}
+	k.held[key] += 1
+	k.mu.Unlock()
+
+	x, err := k.p.GetContext(ctx)
+	if err != nil {
+		k.release(key)
+	}
+	return x, err
+}
+
+// PutKeyed returns 'x', obtained on behalf of 'key', back to the pool.
+// It panics if 'key' holds no objects.
+func (k *Keyed[T]) PutKeyed(key any, x *T) {
+	k.mu.Lock()
+	n := k.held[key]
+	k.mu.Unlock()
+	if n == 0 {
+		panic(fmt.Sprintf("%T: key %v holds no objects", k, key))
+	}
+
+	k.p.Put(x)
+	k.release(key)
+}
+
+// KeyUsage returns the number of objects held by every key that holds
+// at least one.
+func (k *Keyed[T]) KeyUsage() map[any]int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	m := make(map[any]int, len(k.held))
+	for key, n := range k.held {
+		m[key] = n
+	}
+	return m
+}
+
+// release gives back one object of the quota of 'key'
+func (k *Keyed[T]) release(key any) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.held[key] -= 1; k.held[key] <= 0 {
+		delete(k.held, key)
+	}
+
+	// waiters for different keys share the condition variable; so
+	// wake them all and let each re-check its own key.
+	if k.waiters > 0 {
+		k.cond.Broadcast()
+	}
+}
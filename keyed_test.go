@@ -0,0 +1,64 @@
+package objpool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestKeyed(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+	k := objpool.NewKeyed(o, 2)
+
+	a := k.GetKeyed("alice")
+	b := k.GetKeyed("alice")
+	assert(a != nil && b != nil, "alice below limit")
+	assert(k.GetKeyed("alice") == nil, "alice over limit")
+
+	c := k.GetKeyed("bob")
+	assert(c != nil, "bob starved by alice")
+
+	u := k.KeyUsage()
+	assert(u["alice"] == 2 && u["bob"] == 1 && len(u) == 2, "usage: %v", u)
+
+	// alice blocks until she returns an object
+	got := make(chan *int)
+	go func() {
+		x, err := k.GetKeyedContext(context.Background(), "alice")
+		if err != nil {
+			t.Errorf("alice: %v", err)
+		}
+		got <- x
+	}()
+
+	select {
+	case <-got:
+		t.Fatalf("alice got past her limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	k.PutKeyed("alice", a)
+	select {
+	case x := <-got:
+		assert(x != nil, "alice got nil")
+		k.PutKeyed("alice", x)
+	case <-time.After(time.Second):
+		t.Fatalf("alice not woken")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	k.GetKeyed("alice")
+	_, err := k.GetKeyedContext(ctx, "alice")
+	assert(err == context.DeadlineExceeded, "exp deadline, saw %v", err)
+
+	k.PutKeyed("bob", c)
+	_, ok := k.KeyUsage()["bob"]
+	assert(!ok, "bob still listed")
+	mustPanic(t, func() { k.PutKeyed("bob", c) })
+	k.PutKeyed("alice", b)
+}
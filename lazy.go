@@ -0,0 +1,56 @@
+// lazy.go - defer construction of a pool until first use
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"sync"
+)
+
+// Lazy is a pool that is constructed on first use. It is meant for
+// package level pools in libraries: declaring one doesn't allocate the
+// backing array at init time, so programs that never use the pool never
+// pay for it.
+//
+//	var bufs = objpool.NewLazy[buffer](1024)
+type Lazy[T any] struct {
+	once sync.Once
+	sz   int
+	opts []Option[T]
+	p    *Pool[T]
+}
+
+// NewLazy returns a Lazy pool that calls New(sz, opts...) the first
+// time it is used.
+func NewLazy[T any](sz int, opts ...Option[T]) *Lazy[T] {
+	return &Lazy[T]{
+		sz:   sz,
+		opts: opts,
+	}
+}
+
+// Pool returns the underlying pool, constructing it if needed
+func (l *Lazy[T]) Pool() *Pool[T] {
+	l.once.Do(func() {
+		l.p = New[T](l.sz, l.opts...)
+		l.opts = nil
+	})
+	return l.p
+}
+
+// Get returns a single object from the pool; see Pool.Get
+func (l *Lazy[T]) Get() *T {
+	return l.Pool().Get()
+}
+
+// Put returns the object back to the pool; see Pool.Put
+func (l *Lazy[T]) Put(x *T) {
+	l.Pool().Put(x)
+}
+
+// Avail returns number of free objects in the pool
+func (l *Lazy[T]) Avail() int {
+	return l.Pool().Avail()
+}
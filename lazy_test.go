@@ -0,0 +1,35 @@
+package objpool_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestLazy(t *testing.T) {
+	assert := newAsserter(t)
+
+	var named int
+	opt := func(p *objpool.Pool[int]) {
+		named++
+	}
+
+	l := objpool.NewLazy[int](4, opt)
+	assert(named == 0, "constructed before first use")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			x := l.Get()
+			l.Put(x)
+		}()
+	}
+	wg.Wait()
+
+	assert(named == 1, "constructed %d times", named)
+	assert(l.Avail() == 4, "avail: exp 4, saw %d", l.Avail())
+	assert(l.Pool().Cap() == 4, "cap: exp 4, saw %d", l.Pool().Cap())
+}
@@ -0,0 +1,119 @@
+// lazyelastic.go - pool that constructs objects on demand up to a cap
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LazyElastic is a pool of at most 'max' objects that are constructed
+// on demand: an object is only built the first time none of the
+// already constructed ones is free, and once built it is pooled for
+// reuse. This avoids paying construction cost for capacity that is
+// never used while still capping the total number of objects.
+//
+// Unlike Pool, the objects are individually allocated by the
+// constructor rather than laid out in a backing array.
+type LazyElastic[T any] struct {
+	mu   sync.Mutex
+	ctor func() *T
+
+	max         int
+	constructed int
+
+	// free objects; used as a stack so the hottest objects are reused
+	free []*T
+}
+
+// NewLazyElastic creates a pool that constructs up to 'max' objects on
+// demand by calling 'ctor'.
+func NewLazyElastic[T any](max int, ctor func() *T) *LazyElastic[T] {
+	return &LazyElastic[T]{
+		ctor: ctor,
+		max:  max,
+		free: make([]*T, 0, max),
+	}
+}
+
+// Get returns a free object, constructing a new one if none is free
+// and fewer than 'max' have been constructed. It returns nil if all
+// 'max' objects are checked out. The constructor runs without the pool
+// lock held; if it panics or returns nil, the slot it was constructing
+// is given back.
+func (p *LazyElastic[T]) Get() (x *T) {
+	p.mu.Lock()
+	if n := len(p.free); n > 0 {
+		x := p.free[n-1]
+		p.free[n-1] = nil
+		p.free = p.free[:n-1]
+		p.mu.Unlock()
+		return x
+	}
+
+	if p.constructed == p.max {
+		p.mu.Unlock()
+		return nil
+	}
+
+	// reserve the slot before constructing outside the lock
+	p.constructed += 1
+	p.mu.Unlock()
+
+	defer func() {
+		if x == nil {
+			p.mu.Lock()
+			p.constructed -= 1
+			p.mu.Unlock()
+		}
+	}()
+	return p.ctor()
+}
+
+// Put returns the object back to the pool
+func (p *LazyElastic[T]) Put(x *T) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// more free objects than constructed ones can only mean a double
+	// free somewhere!
+	if len(p.free) == p.constructed {
+		msg := fmt.Sprintf("%T: unexpected q-full", p)
+		panic(msg)
+	}
+	p.free = append(p.free, x)
+}
+
+// Avail returns the number of objects that Get can hand out: free
+// objects plus those that can still be constructed.
+func (p *LazyElastic[T]) Avail() int {
+	p.mu.Lock()
+	n := len(p.free) + p.max - p.constructed
+	p.mu.Unlock()
+	return n
+}
+
+// Constructed returns the number of objects constructed so far
+func (p *LazyElastic[T]) Constructed() int {
+	p.mu.Lock()
+	n := p.constructed
+	p.mu.Unlock()
+	return n
+}
+
+// Max returns the maximum number of objects the pool constructs
+func (p *LazyElastic[T]) Max() int {
+	return p.max
+}
+
+// String returns a string description of the pool
+func (p *LazyElastic[T]) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return fmt.Sprintf("<%T max=%d, constructed=%d, free=%d>",
+		p, p.max, p.constructed, len(p.free))
+}
@@ -0,0 +1,64 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestLazyElastic(t *testing.T) {
+	assert := newAsserter(t)
+
+	var n int
+	ctor := func() *int {
+		n++
+		v := n
+		return &v
+	}
+
+	o := objpool.NewLazyElastic[int](3, ctor)
+	assert(o.Constructed() == 0 && n == 0, "constructed eagerly")
+	assert(o.Avail() == 3, "avail: exp 3, saw %d", o.Avail())
+
+	a := o.Get()
+	o.Put(a)
+	b := o.Get()
+	assert(a == b && n == 1, "returned obj not reused")
+
+	c := o.Get()
+	d := o.Get()
+	assert(c != nil && d != nil && n == 3, "exp 3 constructed, saw %d", n)
+	assert(o.Get() == nil, "exp nil past max")
+	assert(o.Avail() == 0, "avail: exp 0, saw %d", o.Avail())
+
+	o.Put(b)
+	o.Put(c)
+	o.Put(d)
+	mustPanic(t, func() { o.Put(d) })
+	assert(o.Constructed() == 3 && o.Max() == 3, "constructed %d", o.Constructed())
+}
+
+func TestLazyElasticCtorFails(t *testing.T) {
+	assert := newAsserter(t)
+
+	var fail, boom bool
+	ctor := func() *int {
+		if boom {
+			panic("boom")
+		}
+		if fail {
+			return nil
+		}
+		return new(int)
+	}
+
+	o := objpool.NewLazyElastic[int](2, ctor)
+	fail = true
+	assert(o.Get() == nil, "nil ctor handed out an object")
+	boom = true
+	mustPanic(t, func() { o.Get() })
+	assert(o.Constructed() == 0, "failed ctor kept its slot: %d", o.Constructed())
+
+	fail, boom = false, false
+	assert(o.Get() != nil && o.Get() != nil, "slots lost to failed ctors")
+}
@@ -0,0 +1,129 @@
+// lockfree.go - lock-free fixed size pool built on a Treiber stack
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+// LockFree is a fixed pool of objects of type 'T' whose free list is a
+// lock-free Treiber stack of slot indices. Get pops and Put pushes,
+// each with a CAS retry loop; there is no mutex. Being a stack, it has
+// LIFO semantics: the most recently returned object is handed out next,
+// which is good for cache locality.
+//
+// The stack is made of indices into the backing array rather than of
+// heap allocated nodes. The head packs the index of the top slot with a
+// version that is bumped on every successful CAS; so a head that was
+// popped and pushed back between a Load and a CAS (the classic ABA
+// problem) has a different version and the CAS fails.
+//
+// Like Pool, Get returns nil when the pool is empty; Put always panics
+// on a double free or on an object that doesn't belong to the pool.
+type LockFree[T any] struct {
+	// version in the upper 32 bits; index+1 of the top slot in the
+	// lower 32 bits, 0 means empty.
+	head atomic.Uint64
+
+	avail atomic.Int64
+
+	// next[i] is index+1 of the slot below i on the stack
+	next []atomic.Int32
+
+	// free[i] is 1 if slot i is on the stack
+	free []atomic.Int32
+
+	arr []T
+}
+
+// NewLockFree creates a new lock-free pool of 'sz' objects of type 'T'.
+// 'sz' must fit in an int32 and T must not be zero sized.
+func NewLockFree[T any](sz int) *LockFree[T] {
+	var zero T
+	if unsafe.Sizeof(zero) == 0 {
+		panic(fmt.Sprintf("objpool: lock-free pool of zero sized %T", zero))
+	}
+	if int64(sz) >= 1<<31-1 {
+		panic(fmt.Sprintf("objpool: lock-free pool size %d too large", sz))
+	}
+
+	p := &LockFree[T]{
+		next: make([]atomic.Int32, sz),
+		free: make([]atomic.Int32, sz),
+		arr:  make([]T, sz),
+	}
+
+	// slot 0 ends up at the top of the stack
+	for i := 0; i < sz; i++ {
+		p.next[i].Store(int32(i + 2))
+		p.free[i].Store(1)
+	}
+	if sz > 0 {
+		p.next[sz-1].Store(0)
+		p.head.Store(1)
+	}
+	p.avail.Store(int64(sz))
+	return p
+}
+
+// Get returns a single object from the pool. It returns nil if the pool
+// has exhausted its capacity.
+func (p *LockFree[T]) Get() *T {
+	for {
+		h := p.head.Load()
+		top := int32(h)
+		if top == 0 {
+			return nil
+		}
+
+		i := top - 1
+		nh := (h>>32+1)<<32 | uint64(uint32(p.next[i].Load()))
+		if p.head.CompareAndSwap(h, nh) {
+			p.free[i].Store(0)
+			p.avail.Add(-1)
+			return &p.arr[i]
+		}
+	}
+}
+
+// Put returns the object back to the pool
+func (p *LockFree[T]) Put(x *T) {
+	i := slotOf(p.arr, x)
+	if i < 0 {
+		panic(fmt.Sprintf("%T: %p doesn't belong to the pool", p, x))
+	}
+
+	if !p.free[i].CompareAndSwap(0, 1) {
+		panic(fmt.Sprintf("%T: double free of %p (slot %d)", p, x, i))
+	}
+
+	for {
+		h := p.head.Load()
+		p.next[i].Store(int32(h))
+		nh := (h>>32+1)<<32 | uint64(uint32(i+1))
+		if p.head.CompareAndSwap(h, nh) {
+			p.avail.Add(1)
+			return
+		}
+	}
+}
+
+// Avail returns number of free objects in the pool
+func (p *LockFree[T]) Avail() int {
+	return int(p.avail.Load())
+}
+
+// Cap returns the capacity of the pool
+func (p *LockFree[T]) Cap() int {
+	return len(p.arr)
+}
+
+// String returns a string description of the pool
+func (p *LockFree[T]) String() string {
+	return fmt.Sprintf("<%T cap=%d, free=%d>", p, len(p.arr), p.Avail())
+}
@@ -0,0 +1,73 @@
+package objpool_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestLockFree(t *testing.T) {
+	assert := newAsserter(t)
+
+	size := 3
+	o := objpool.NewLockFree[int](size)
+	assert(o.Avail() == size, "avail: exp %d, saw %d", size, o.Avail())
+
+	var v []*int
+	for i := 0; i < size; i++ {
+		x := o.Get()
+		assert(x != nil, "%d: expected obj; got nil", i)
+		v = append(v, x)
+	}
+	assert(o.Get() == nil, "exp nil from empty pool")
+
+	// LIFO
+	o.Put(v[1])
+	o.Put(v[2])
+	assert(o.Get() == v[2], "not LIFO")
+
+	r := mustPanic(t, func() { o.Put(v[1]) })
+	assert(strings.Contains(r.(string), "double free"), "wrong panic: %v", r)
+
+	var foreign int
+	mustPanic(t, func() { o.Put(&foreign) })
+
+	o.Put(v[0])
+	o.Put(v[2])
+	assert(o.Avail() == size, "avail: exp %d, saw %d", size, o.Avail())
+}
+
+func TestLockFreeConcurrent(t *testing.T) {
+	assert := newAsserter(t)
+
+	const size = 8
+	o := objpool.NewLockFree[int](size)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < 10000; j++ {
+				x := o.Get()
+				if x == nil {
+					continue
+				}
+
+				// nobody else may hold x while we do
+				*x = id
+				for k := 0; k < 4; k++ {
+					if *x != id {
+						t.Errorf("aliased obj %p", x)
+						return
+					}
+				}
+				o.Put(x)
+			}
+		}(i + 1)
+	}
+	wg.Wait()
+	assert(o.Avail() == size, "avail: exp %d, saw %d", size, o.Avail())
+}
@@ -0,0 +1,56 @@
+// logger.go - optional logging of pool lifecycle events
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// Logger receives human readable descriptions of pool events such as
+// exhaustion, Reset, Freeze and SwapBacking; it decouples the package
+// from any particular logging library. Debugf may be called with the
+// pool lock held and must not call back into the pool.
+type Logger interface {
+	Debugf(format string, args ...any)
+}
+
+// SetLogger installs 'l' as the logger of the pool; a nil 'l' turns
+// logging off, which is the default. Without a logger, the only cost of
+// an event is a single atomic load.
+//
+// Warnings (overflowing or duplicate Puts, overdue borrows and the
+// switch to the fallback allocator) go to the logger as well; without
+// one they are silent.
+func (p *Pool[T]) SetLogger(l Logger) {
+	if l == nil {
+		p.logger.Store(nil)
+		return
+	}
+	p.logger.Store(&l)
+}
+
+// debugf logs an event, prefixed with the pool label, if the pool has a
+// logger.
+func (p *Pool[T]) debugf(format string, args ...any) {
+	if l := p.logger.Load(); l != nil {
+		(*l).Debugf("%s: "+format, append([]any{p.label()}, args...)...)
+	}
+}
+
+// warnf logs a warning, which carries its own prefix, if the pool has
+// a logger.
+func (p *Pool[T]) warnf(format string, args ...any) {
+	if l := p.logger.Load(); l != nil {
+		(*l).Debugf(format, args...)
+	}
+}
+
+// miss counts a Get that found the pool empty; the caller must hold the
+// lock.
+func (p *Pool[T]) miss() {
+	p.misses += 1
+
+	// don't pay for boxing the args on the hot path
+	if p.logger.Load() != nil {
+		p.debugf("exhausted (cap %d)", p.size)
+	}
+}
@@ -0,0 +1,64 @@
+package objpool_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+type recLogger struct {
+	msgs []string
+}
+
+func (r *recLogger) Debugf(format string, args ...any) {
+	r.msgs = append(r.msgs, fmt.Sprintf(format, args...))
+}
+
+func (r *recLogger) has(s string) bool {
+	for _, m := range r.msgs {
+		if strings.Contains(m, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLogger(t *testing.T) {
+	assert := newAsserter(t)
+
+	var r recLogger
+	o := objpool.New[int](1, objpool.WithName[int]("logged"), objpool.WithDropOnOverflow[int]())
+	o.SetLogger(&r)
+
+	a := o.Get()
+	assert(o.Get() == nil, "pool not exhausted")
+	assert(r.has("logged") && r.has("exhausted"), "exhaustion not logged: %q", r.msgs)
+
+	o.Put(a)
+	o.Put(a)
+	assert(r.has("dropped"), "overflow not logged: %q", r.msgs)
+
+	assert(o.Reset() == nil, "reset")
+	assert(r.has("reset"), "reset not logged: %q", r.msgs)
+
+	// off again
+	o.SetLogger(nil)
+	n := len(r.msgs)
+	o.Reset()
+	assert(len(r.msgs) == n, "logged after SetLogger(nil)")
+
+	// and warnings don't fall back to the standard logger
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	a = o.Get()
+	o.Put(a)
+	o.Put(a)
+	assert(buf.Len() == 0, "warning without a logger: %q", buf.String())
+}
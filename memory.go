@@ -0,0 +1,42 @@
+// memory.go - estimate memory footprint of the pool
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"unsafe"
+)
+
+// EstimatedBytes returns an estimate of the memory held by the pool:
+// the backing array, the free queue and any per-slot tracking state
+// that has been allocated (ownership tracking, bad marks, epochs). It
+// helps operators log and budget the aggregate footprint of many pools.
+//
+// For types that reference out of line memory (e.g. slices or maps) the
+// backing array only accounts for the headers; a sizer configured via
+// WithSizer is called on every backing object to add the bytes it
+// references.
+func (p *Pool[T]) EstimatedBytes() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var zero T
+	var ptr *T
+
+	n := int64(p.nobjs()) * int64(unsafe.Sizeof(zero))
+	n += int64(len(p.q)+len(p.objs)) * int64(unsafe.Sizeof(ptr))
+	n += int64(len(p.idx)) * 4
+	n += int64(len(p.owned)+len(p.bad)) * 8
+	if p.ep != nil {
+		n += int64(len(p.ep.slot)) * 8
+	}
+
+	if p.sizer != nil {
+		for i := 0; i < p.nobjs(); i++ {
+			n += p.sizer(p.obj(i))
+		}
+	}
+	return n
+}
@@ -0,0 +1,75 @@
+// merge.go - consolidate two pools into one
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Merge creates a pool whose capacity is the sum of the capacities of
+// 'a' and 'b' and whose objects are those of both pools; it's meant for
+// reconfiguring a service to use fewer, larger pools. Both pools must
+// be idle - pointers to checked out objects would otherwise outlive the
+// pool they belong to - and Merge returns ErrInUse if they aren't. It
+// also fails for queues and frozen pools.
+//
+// The objects aren't copied: the merged pool manages the very objects
+// of 'a' and 'b', which stay in the backing memory of the original
+// pools, and that memory lives on for as long as the merged pool does.
+// So 'a' and 'b' are left exhausted and must not be used afterwards.
+// The merged pool hands out the objects of 'a' first and inherits the
+// name and hooks of 'a'; like a pool created by FromFactory, it has no
+// backing array of its own.
+func Merge[T any](a, b *Pool[T]) (*Pool[T], error) {
+	if a == b {
+		return nil, fmt.Errorf("objpool: can't merge a pool with itself")
+	}
+
+	// lock in a global order so concurrent merges can't deadlock
+	first, second := a, b
+	if uintptr(unsafe.Pointer(b)) < uintptr(unsafe.Pointer(a)) {
+		first, second = b, a
+	}
+	first.mu.Lock()
+	defer first.mu.Unlock()
+	second.mu.Lock()
+	defer second.mu.Unlock()
+
+	for _, p := range []*Pool[T]{a, b} {
+		switch {
+		case p.queue:
+			return nil, fmt.Errorf("objpool: can't merge a queue")
+		case p.frozen.Load():
+			return nil, ErrFrozen
+		case p.avail != p.size:
+			return nil, ErrInUse
+		}
+	}
+
+	objs := make([]*T, 0, a.size+b.size)
+	for _, p := range []*Pool[T]{a, b} {
+		p.freeEach(func(x *T) {
+			objs = append(objs, x)
+		})
+	}
+
+	o, err := fromObjs(objs, WithName[T](a.name))
+	if err != nil {
+		return nil, fmt.Errorf("objpool: merge: %w", err)
+	}
+	o.reset = a.reset
+	o.cleanup = a.cleanup
+	o.init = a.init
+	o.sizer = a.sizer
+
+	// every object now belongs to the merged pool
+	for _, p := range []*Pool[T]{a, b} {
+		p.rd = p.wr
+		p.avail = 0
+	}
+	return o, nil
+}
@@ -0,0 +1,41 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestMerge(t *testing.T) {
+	assert := newAsserter(t)
+
+	a := objpool.New[int](2, objpool.WithName[int]("a"))
+	b := objpool.New[int](3)
+
+	x := b.Get()
+	_, err := objpool.Merge(a, b)
+	assert(err == objpool.ErrInUse, "merged a busy pool: %v", err)
+	b.Put(x)
+
+	_, err = objpool.Merge(a, a)
+	assert(err != nil, "merged a pool with itself")
+
+	orig := make(map[*int]bool)
+	a.ForEach(func(x *int) { orig[x] = true })
+	b.ForEach(func(x *int) { orig[x] = true })
+
+	m, err := objpool.Merge(a, b)
+	assert(err == nil, "merge: %v", err)
+	assert(m.Cap() == 5 && m.Avail() == 5, "merged: cap %d avail %d", m.Cap(), m.Avail())
+	assert(a.Avail() == 0 && b.Avail() == 0, "originals not exhausted")
+
+	v := m.GetN(5)
+	for _, x := range v {
+		assert(orig[x], "%p isn't an original object", x)
+		delete(orig, x)
+	}
+	assert(len(orig) == 0, "objects lost in merge: %d", len(orig))
+
+	m.PutN(v)
+	assert(m.Reset() == nil && m.Avail() == 5, "reset of merged pool")
+}
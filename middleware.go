@@ -0,0 +1,30 @@
+// middleware.go - layer cross-cutting concerns around Get
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// GetFunc is the signature of Get as seen by middleware
+type GetFunc[T any] func() *T
+
+// Use wraps Get with the middleware 'mw': it is handed the next GetFunc
+// of the chain and returns a GetFunc that does its work around calling
+// it, e.g. for logging, timing or retries. Middlewares compose in
+// registration order; the first one registered is the outermost. Pools
+// without middleware don't pay for the chain. Only Get is wrapped; the
+// batch and blocking variants are not.
+func (p *Pool[T]) Use(mw func(next GetFunc[T]) GetFunc[T]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.mws = append(p.mws, mw)
+
+	// rebuild the chain from the innermost out
+	fn := GetFunc[T](p.getNow)
+	for i := len(p.mws) - 1; i >= 0; i-- {
+		fn = p.mws[i](fn)
+	}
+	p.mw.Store(&fn)
+	p.slow.Store(true)
+}
@@ -0,0 +1,73 @@
+// mlock.go - lock the memory of the pool into RAM
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// LockMemory locks the pages of the backing array and the free queue
+// into RAM via mlock(2), so that the pooled objects can never be swapped
+// out; this eliminates page fault jitter for latency critical users who
+// already prewarm the pool. It is limited by RLIMIT_MEMLOCK and returns
+// the error of the system call if the limit is exceeded. Memory that
+// objects reference (e.g. the contents of a slice field) isn't locked.
+//
+// LockMemory returns errors.ErrUnsupported on platforms without mlock
+// and an error for pools without a backing array. Call UnlockMemory
+// before SwapBacking; the old array otherwise stays locked.
+func (p *Pool[T]) LockMemory() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.arr) == 0 && p.size > 0 {
+		return fmt.Errorf("objpool: pool has no backing array")
+	}
+	for _, b := range p.regions() {
+		if err := mlock(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnlockMemory undoes LockMemory
+func (p *Pool[T]) UnlockMemory() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, b := range p.regions() {
+		if err := munlock(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// regions returns the memory of the backing array and of the free queue
+// as byte slices; the caller must hold the lock.
+func (p *Pool[T]) regions() [][]byte {
+	var v [][]byte
+
+	add := func(ptr unsafe.Pointer, n uintptr) {
+		if n > 0 {
+			v = append(v, unsafe.Slice((*byte)(ptr), n))
+		}
+	}
+
+	var zero T
+	if len(p.arr) > 0 {
+		add(unsafe.Pointer(&p.arr[0]), uintptr(len(p.arr))*unsafe.Sizeof(zero))
+	}
+	if len(p.q) > 0 {
+		add(unsafe.Pointer(&p.q[0]), uintptr(len(p.q))*unsafe.Sizeof(p.q[0]))
+	}
+	if len(p.idx) > 0 {
+		add(unsafe.Pointer(&p.idx[0]), uintptr(len(p.idx))*unsafe.Sizeof(p.idx[0]))
+	}
+	return v
+}
@@ -0,0 +1,20 @@
+// mlock_other.go - stubs for platforms without mlock(2)
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package objpool
+
+import (
+	"errors"
+)
+
+func mlock(b []byte) error {
+	return errors.ErrUnsupported
+}
+
+func munlock(b []byte) error {
+	return errors.ErrUnsupported
+}
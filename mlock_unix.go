@@ -0,0 +1,20 @@
+// mlock_unix.go - mlock(2) on platforms that have it
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package objpool
+
+import (
+	"syscall"
+)
+
+func mlock(b []byte) error {
+	return syscall.Mlock(b)
+}
+
+func munlock(b []byte) error {
+	return syscall.Munlock(b)
+}
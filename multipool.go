@@ -0,0 +1,174 @@
+// multipool.go - size class multiplexed pool of byte buffers
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"sort"
+	"unsafe"
+)
+
+// SizeClass describes one size class of a MultiPool: 'Count' buffers
+// of 'Size' bytes each.
+type SizeClass struct {
+	Size  int
+	Count int
+}
+
+// MultiPool is a set of fixed pools of byte buffers, one per size
+// class. Get(n) is served by the smallest class whose buffers can hold
+// 'n' bytes; buffers are returned to their class by their capacity.
+// The buffers of each class are carved out of a single contiguous slab.
+type MultiPool struct {
+	classes []*byteClass
+}
+
+type byteClass struct {
+	size int
+	slab []byte
+	pool *Pool[[]byte]
+}
+
+// NewMultiPool creates a pool with the given size classes. The size of
+// each class must be positive and unique.
+func NewMultiPool(classes ...SizeClass) (*MultiPool, error) {
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("multipool: no size classes")
+	}
+
+	cv := make([]SizeClass, len(classes))
+	copy(cv, classes)
+	sort.Slice(cv, func(i, j int) bool {
+		return cv[i].Size < cv[j].Size
+	})
+
+	m := &MultiPool{
+		classes: make([]*byteClass, 0, len(cv)),
+	}
+
+	for i, c := range cv {
+		if c.Size <= 0 || c.Count <= 0 {
+			return nil, fmt.Errorf("multipool: invalid size class %d x %d", c.Size, c.Count)
+		}
+		if i > 0 && cv[i-1].Size == c.Size {
+			return nil, fmt.Errorf("multipool: duplicate size class %d", c.Size)
+		}
+
+		slab := make([]byte, c.Size*c.Count)
+		p := New[[]byte](c.Count)
+		for j := range p.arr {
+			off := j * c.Size
+			p.arr[j] = slab[off : off+c.Size : off+c.Size]
+		}
+
+		bc := &byteClass{
+			size: c.Size,
+			slab: slab,
+			pool: p,
+		}
+		m.classes = append(m.classes, bc)
+	}
+	return m, nil
+}
+
+// Get returns a buffer of length 'n' from the smallest size class that
+// can hold it. It returns nil if 'n' is larger than the largest class
+// or if that class has exhausted its capacity; Get never falls back to
+// a larger class.
+func (m *MultiPool) Get(n int) []byte {
+	c := m.class(n)
+	if c == nil {
+		return nil
+	}
+
+	b := c.pool.Get()
+	if b == nil {
+		return nil
+	}
+	return (*b)[:n]
+}
+
+// Put returns the buffer 'b' to its size class. 'b' must have been
+// obtained from Get on the same MultiPool; Put panics otherwise.
+func (m *MultiPool) Put(b []byte) {
+	x := m.lookup(b)
+	if x == nil {
+		msg := fmt.Sprintf("%T: buffer %p (cap %d) doesn't belong to the pool",
+			m, unsafe.SliceData(b), cap(b))
+		panic(msg)
+	}
+	x.pool.Put(&x.pool.arr[x.index(b)])
+}
+
+// Stats returns the stats for each size class in increasing order of
+// size.
+func (m *MultiPool) Stats() []Stats {
+	v := make([]Stats, len(m.classes))
+	for i, c := range m.classes {
+		v[i] = c.pool.Stats()
+	}
+	return v
+}
+
+// String returns a string description of the pool
+func (m *MultiPool) String() string {
+	s := fmt.Sprintf("<%T", m)
+	for _, c := range m.classes {
+		s += fmt.Sprintf(" [%d: %d/%d]", c.size, c.pool.Avail(), c.pool.Cap())
+	}
+	return s + ">"
+}
+
+// class returns the smallest size class that fits 'n' bytes
+func (m *MultiPool) class(n int) *byteClass {
+	if n < 0 {
+		return nil
+	}
+
+	i := sort.Search(len(m.classes), func(i int) bool {
+		return m.classes[i].size >= n
+	})
+	if i == len(m.classes) {
+		return nil
+	}
+	return m.classes[i]
+}
+
+// lookup returns the size class that 'b' was carved out of
+func (m *MultiPool) lookup(b []byte) *byteClass {
+	i := sort.Search(len(m.classes), func(i int) bool {
+		return m.classes[i].size >= cap(b)
+	})
+	if i == len(m.classes) {
+		return nil
+	}
+
+	c := m.classes[i]
+	if c.size != cap(b) || c.index(b) < 0 {
+		return nil
+	}
+	return c
+}
+
+// index returns the slot of 'b' in the slab or -1
+func (c *byteClass) index(b []byte) int {
+	base := uintptr(unsafe.Pointer(unsafe.SliceData(c.slab)))
+	ptr := uintptr(unsafe.Pointer(unsafe.SliceData(b)))
+	if ptr < base {
+		return -1
+	}
+
+	off := ptr - base
+	if off%uintptr(c.size) != 0 {
+		return -1
+	}
+
+	i := int(off / uintptr(c.size))
+	if i >= len(c.pool.arr) {
+		return -1
+	}
+	return i
+}
@@ -0,0 +1,49 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestMultiPool(t *testing.T) {
+	assert := newAsserter(t)
+
+	m, err := objpool.NewMultiPool(
+		objpool.SizeClass{Size: 1024, Count: 1},
+		objpool.SizeClass{Size: 64, Count: 2},
+		objpool.SizeClass{Size: 256, Count: 2},
+	)
+	assert(err == nil, "new: %s", err)
+
+	a := m.Get(10)
+	assert(len(a) == 10 && cap(a) == 64, "a: len %d cap %d", len(a), cap(a))
+
+	b := m.Get(64)
+	assert(cap(b) == 64, "b: cap %d", cap(b))
+
+	// class exhausted; no fallback to the larger class
+	c := m.Get(1)
+	assert(c == nil, "exp nil from exhausted class")
+
+	d := m.Get(200)
+	assert(cap(d) == 256, "d: cap %d", cap(d))
+
+	e := m.Get(2000)
+	assert(e == nil, "exp nil for oversized request")
+
+	m.Put(a)
+	c = m.Get(1)
+	assert(c != nil && cap(c) == 64, "exp reuse of returned buffer")
+
+	st := m.Stats()
+	assert(len(st) == 3, "stats: exp 3 classes, saw %d", len(st))
+	assert(st[0].InUse == 2 && st[1].InUse == 1 && st[2].InUse == 0,
+		"in-use: %d %d %d", st[0].InUse, st[1].InUse, st[2].InUse)
+
+	r := mustPanic(t, func() { m.Put(make([]byte, 64)) })
+	assert(r != nil, "foreign buffer accepted")
+
+	_, err = objpool.NewMultiPool(objpool.SizeClass{Size: 8, Count: 1}, objpool.SizeClass{Size: 8, Count: 2})
+	assert(err != nil, "duplicate class accepted")
+}
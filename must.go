@@ -0,0 +1,89 @@
+// must.go - assert the fill state of a pool
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxMustSlots is the number of slots listed in the panic message of
+// MustBeFull and MustBeEmpty
+const maxMustSlots = 16
+
+// MustBeFull panics unless every object is in the pool, i.e. nothing
+// is checked out. The panic message includes the ring state and the
+// slots that are checked out, which makes it suitable as a one line
+// teardown check in tests (a leaked object shows up as a failure that
+// names its slot) and as an invariant check in calling code.
+func (p *Pool[T]) MustBeFull() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == p.size {
+		return
+	}
+
+	// everything not in the free queue is checked out
+	out := newBitset(p.nobjs())
+	for i := 0; i < p.nobjs(); i++ {
+		out.set(i)
+	}
+	p.freeEach(func(x *T) {
+		if i := p.slot(x); i >= 0 {
+			out.clr(i)
+		}
+	})
+
+	var v []int
+	for i := 0; i < p.nobjs(); i++ {
+		if out.isset(i) {
+			v = append(v, i)
+		}
+	}
+	panic(p.mustMsg("full", "checked out", v, p.size-p.avail))
+}
+
+// MustBeEmpty panics unless every object is checked out. Like
+// MustBeFull, the panic message includes the ring state; it lists the
+// slots that are still free.
+func (p *Pool[T]) MustBeEmpty() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == 0 {
+		return
+	}
+
+	var v []int
+	p.freeEach(func(x *T) {
+		if len(v) < maxMustSlots {
+			v = append(v, p.slot(x))
+		}
+	})
+	panic(p.mustMsg("empty", "free", v, p.avail))
+}
+
+// mustMsg describes a pool that isn't in the 'exp' state; 'v' are the
+// first of 'n' slots that are 'what'. The caller must hold the lock.
+func (p *Pool[T]) mustMsg(exp, what string, v []int, n int) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: expected %s pool; cap=%d rd=%d wr=%d avail=%d; %s slots [",
+		p.label(), exp, p.size, p.rd, p.wr, p.avail, what)
+	v = v[:min(len(v), maxMustSlots)]
+	for i, j := range v {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%d", j)
+	}
+	if len(v) < n {
+		fmt.Fprintf(&b, " ... (+%d more)", n-len(v))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
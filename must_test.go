@@ -0,0 +1,31 @@
+package objpool_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestMustBe(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](20, objpool.WithName[int]("conns"))
+	o.MustBeFull()
+
+	v := o.GetN(18)
+	o.Put(v[3])
+	r := mustPanic(t, o.MustBeFull)
+	msg, _ := r.(string)
+	assert(strings.Contains(msg, "conns") && strings.Contains(msg, "expected full"), "msg: %q", msg)
+	assert(strings.Contains(msg, "avail=3"), "no ring state: %q", msg)
+	assert(strings.Contains(msg, "checked out slots [0 1 2 4 5"), "no slots: %q", msg)
+	assert(strings.Contains(msg, "(+1 more)"), "no elision: %q", msg)
+
+	r = mustPanic(t, o.MustBeEmpty)
+	msg, _ = r.(string)
+	assert(strings.Contains(msg, "free slots [18 19 3]"), "msg: %q", msg)
+
+	o.GetN(3)
+	o.MustBeEmpty()
+}
@@ -0,0 +1,116 @@
+// numa.go - per-node backing arrays for NUMA locality
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// NUMA is a pool made of one fixed Pool per NUMA node, each with its
+// own backing array, so that large objects are handed out from memory
+// local to the caller. Get first tries the pool of the node returned by
+// the node hint and then the others in order; Put always returns an
+// object to the pool of the array it came from.
+//
+// Affinity is best-effort: Go has no portable, cheap way to learn the
+// CPU or node a goroutine runs on, and goroutines migrate between CPUs
+// anyway. By default Get starts at the nodes in round-robin order,
+// which spreads the load evenly instead of draining node 0 first;
+// callers that can do better (e.g. via getcpu(2) from
+// golang.org/x/sys/unix) install their own hint with SetNodeHint.
+// Objects may be used on a different node than the one they live on,
+// and the backing arrays are only node local if the OS places each
+// allocation on the node that first touches it.
+type NUMA[T any] struct {
+	nodes []*Pool[T]
+	hint  atomic.Pointer[func() int]
+
+	// the start node of Gets without a hint
+	next atomic.Uint64
+}
+
+// NewNUMA creates a pool of 'nodes' backing arrays of 'szPerNode'
+// objects each.
+func NewNUMA[T any](szPerNode int, nodes int) *NUMA[T] {
+	if nodes <= 0 {
+		panic(fmt.Sprintf("objpool: invalid number of numa nodes %d", nodes))
+	}
+
+	n := &NUMA[T]{
+		nodes: make([]*Pool[T], nodes),
+	}
+	for i := range n.nodes {
+		n.nodes[i] = New[T](szPerNode)
+	}
+	return n
+}
+
+// SetNodeHint installs 'fn' to pick the preferred node for Get; its
+// result is taken modulo the number of nodes. A nil 'fn' restores the
+// default round-robin order.
+func (n *NUMA[T]) SetNodeHint(fn func() int) {
+	if fn == nil {
+		n.hint.Store(nil)
+		return
+	}
+	n.hint.Store(&fn)
+}
+
+// Get returns a single object, preferring the node picked by the node
+// hint. It returns nil if every node is exhausted.
+func (n *NUMA[T]) Get() *T {
+	var h int
+	if fn := n.hint.Load(); fn != nil {
+		if h = (*fn)() % len(n.nodes); h < 0 {
+			h += len(n.nodes)
+		}
+	} else {
+		h = int((n.next.Add(1) - 1) % uint64(len(n.nodes)))
+	}
+
+	for i := range n.nodes {
+		if x := n.nodes[(h+i)%len(n.nodes)].Get(); x != nil {
+			return x
+		}
+	}
+	return nil
+}
+
+// Put returns the object to the backing array it came from; it panics
+// if the object doesn't belong to any of them.
+func (n *NUMA[T]) Put(x *T) {
+	if !PutTo(x, n.nodes...) {
+		msg := fmt.Sprintf("%T: %p doesn't belong to the pool", n, x)
+		panic(msg)
+	}
+}
+
+// Avail returns number of free objects across all nodes
+func (n *NUMA[T]) Avail() int {
+	var a int
+	for _, p := range n.nodes {
+		a += p.Avail()
+	}
+	return a
+}
+
+// Node returns the pool backing node 'i'
+func (n *NUMA[T]) Node(i int) *Pool[T] {
+	return n.nodes[i]
+}
+
+// ShardStats returns the stats of each node's pool, indexed by node; an
+// empty node next to full ones means the node hint is skewed. Each node
+// is snapshotted under its own lock in turn, so the result is not an
+// atomic view across nodes.
+func (n *NUMA[T]) ShardStats() []Stats {
+	v := make([]Stats, len(n.nodes))
+	for i, p := range n.nodes {
+		v[i] = p.Stats()
+	}
+	return v
+}
@@ -0,0 +1,88 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestNUMA(t *testing.T) {
+	assert := newAsserter(t)
+
+	n := objpool.NewNUMA[int](2, 3)
+	assert(n.Avail() == 6, "avail: exp 6, saw %d", n.Avail())
+
+	node := 2
+	n.SetNodeHint(func() int { return node })
+
+	a := n.Get()
+	assert(n.Node(2).Avail() == 1, "hint ignored")
+
+	// exhaust node 2; Get spills over to the next node
+	n.Get()
+	b := n.Get()
+	assert(n.Node(0).Avail() == 1, "no spill over to node 0")
+
+	// Put returns to the origin array regardless of the hint
+	node = 1
+	n.Put(b)
+	n.Put(a)
+	assert(n.Node(0).Avail() == 2 && n.Node(2).Avail() == 1,
+		"objects returned to the wrong node")
+
+	var foreign int
+	mustPanic(t, func() { n.Put(&foreign) })
+}
+
+func TestNUMARoundRobin(t *testing.T) {
+	assert := newAsserter(t)
+
+	// without a hint, Gets are spread evenly across the nodes
+	n := objpool.NewNUMA[int](4, 3)
+	var v []*int
+	for i := 0; i < 6; i++ {
+		v = append(v, n.Get())
+	}
+	for i, s := range n.ShardStats() {
+		assert(s.InUse == 2, "node %d: exp 2 in use, saw %d", i, s.InUse)
+	}
+
+	// and a nil hint restores the default
+	n.SetNodeHint(func() int { return 0 })
+	n.SetNodeHint(nil)
+	for i := 0; i < 3; i++ {
+		v = append(v, n.Get())
+	}
+	for i, s := range n.ShardStats() {
+		assert(s.InUse == 3, "node %d: exp 3 in use, saw %d", i, s.InUse)
+	}
+
+	for _, x := range v {
+		n.Put(x)
+	}
+	assert(n.Avail() == 12, "avail: %d", n.Avail())
+}
+
+func TestNUMAShardStats(t *testing.T) {
+	assert := newAsserter(t)
+
+	n := objpool.NewNUMA[int](2, 3)
+	n.SetNodeHint(func() int { return 1 })
+
+	a := n.Get()
+	b := n.Get()
+	c := n.Get()
+
+	v := n.ShardStats()
+	assert(len(v) == 3, "shards: exp 3, saw %d", len(v))
+	assert(v[0].InUse == 0 && v[1].InUse == 2 && v[2].InUse == 1,
+		"in use: saw %d %d %d", v[0].InUse, v[1].InUse, v[2].InUse)
+	assert(v[0].Avail == 2 && v[1].Avail == 0, "avail: saw %d %d", v[0].Avail, v[1].Avail)
+
+	n.Put(a)
+	n.Put(b)
+	n.Put(c)
+	for i, s := range n.ShardStats() {
+		assert(s.InUse == 0, "shard %d: in use %d", i, s.InUse)
+	}
+}
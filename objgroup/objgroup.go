@@ -0,0 +1,67 @@
+// objgroup.go - bounded concurrency backed by an object pool
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+// objgroup ties the backpressure of an objpool.Pool to the common
+// errgroup pattern: run a function over a set of tasks with at most
+// as many in flight as there are objects in the pool.
+package objgroup
+
+import (
+	"context"
+	"sync"
+
+	"github.com/opencoff/go-objpool"
+)
+
+// RunBounded runs 'fn' concurrently over every element of 'tasks'.
+// Each invocation is handed an object acquired from 'p' - blocking
+// while the pool is exhausted - and the object is returned to the pool
+// when 'fn' returns. The concurrency is thus bounded by the capacity of
+// the pool.
+//
+// The first error returned by 'fn' cancels the context passed to the
+// remaining invocations and stops new tasks from starting; RunBounded
+// waits for all running invocations to finish and returns that first
+// error. If 'ctx' is cancelled, no new tasks are started and its error
+// is returned. Every acquired object is returned to the pool before
+// RunBounded returns.
+func RunBounded[T, A any](ctx context.Context, p *objpool.Pool[T], tasks []A,
+	fn func(ctx context.Context, obj *T, task A) error) error {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var err error
+
+	fail := func(e error) {
+		once.Do(func() {
+			err = e
+			cancel()
+		})
+	}
+
+	for i := range tasks {
+		x, e := p.GetContext(ctx)
+		if e != nil {
+			fail(e)
+			break
+		}
+
+		wg.Add(1)
+		go func(x *T, task A) {
+			defer wg.Done()
+			defer p.Put(x)
+
+			if e := fn(ctx, x, task); e != nil {
+				fail(e)
+			}
+		}(x, tasks[i])
+	}
+
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,105 @@
+package objgroup_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+	"github.com/opencoff/go-objpool/objgroup"
+)
+
+func TestRunBounded(t *testing.T) {
+	const size = 3
+	p := objpool.New[int](size)
+
+	tasks := make([]int, 20)
+	for i := range tasks {
+		tasks[i] = i
+	}
+
+	var inflight, peak, sum atomic.Int64
+	err := objgroup.RunBounded(context.Background(), p, tasks,
+		func(ctx context.Context, x *int, task int) error {
+			n := inflight.Add(1)
+			defer inflight.Add(-1)
+			for {
+				m := peak.Load()
+				if n <= m || peak.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			sum.Add(int64(task))
+			return nil
+		})
+
+	if err != nil {
+		t.Fatalf("run: %s", err)
+	}
+	if peak.Load() > size {
+		t.Fatalf("concurrency: exp <= %d, saw %d", size, peak.Load())
+	}
+	if sum.Load() != 190 {
+		t.Fatalf("sum: exp 190, saw %d", sum.Load())
+	}
+	if p.Avail() != size {
+		t.Fatalf("leaked objects: avail %d", p.Avail())
+	}
+}
+
+func TestRunBoundedError(t *testing.T) {
+	const size = 2
+	p := objpool.New[int](size)
+
+	tasks := make([]int, 100)
+	boom := errors.New("boom")
+
+	var ran atomic.Int64
+	err := objgroup.RunBounded(context.Background(), p, tasks,
+		func(ctx context.Context, x *int, task int) error {
+			if ran.Add(1) == 3 {
+				return boom
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Millisecond):
+			}
+			return nil
+		})
+
+	if err != boom {
+		t.Fatalf("exp boom, saw %v", err)
+	}
+	if ran.Load() == int64(len(tasks)) {
+		t.Fatalf("error didn't stop new tasks")
+	}
+	if p.Avail() != size {
+		t.Fatalf("leaked objects: avail %d", p.Avail())
+	}
+
+	// a cancelled context starts nothing, even if the pool could run
+	// every task at once
+	p = objpool.New[int](2 * len(tasks))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ran.Store(0)
+	err = objgroup.RunBounded(ctx, p, tasks,
+		func(ctx context.Context, x *int, task int) error {
+			ran.Add(1)
+			return nil
+		})
+
+	if err != context.Canceled {
+		t.Fatalf("exp cancelled, saw %v", err)
+	}
+	if ran.Load() != 0 {
+		t.Fatalf("cancelled context ran %d tasks", ran.Load())
+	}
+	if p.Avail() != p.Cap() {
+		t.Fatalf("leaked objects: avail %d", p.Avail())
+	}
+}
@@ -10,8 +10,37 @@
 package objpool
 
 import (
+	"errors"
 	"fmt"
+	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrInUse is returned by operations that require every object to
+	// be in the pool when some are still checked out.
+	ErrInUse = errors.New("objpool: objects are checked out")
+
+	// ErrForeign is returned when an object doesn't belong to the pool
+	ErrForeign = errors.New("objpool: object doesn't belong to the pool")
+
+	// ErrNotCheckedOut is returned when an operation requires a checked
+	// out object but the object is free
+	ErrNotCheckedOut = errors.New("objpool: object is not checked out")
+
+	// ErrFrozen is returned by operations that would change a pool
+	// that has been frozen via Freeze
+	ErrFrozen = errors.New("objpool: pool is frozen")
+
+	// ErrBadState is returned by ImportState when the state is corrupt
+	// or doesn't describe the pool
+	ErrBadState = errors.New("objpool: invalid pool state")
+
+	// ErrInvalid is returned when an operation doesn't apply to the
+	// pool or is given an invalid argument
+	ErrInvalid = errors.New("objpool: invalid operation")
 )
 
 // Pool represents a fixed pool of objects for type 'T'. Callers can allocate/free
@@ -22,77 +51,727 @@ type Pool[T any] struct {
 	rd, wr int
 	avail  int
 
-	q   []*T
+	// true if none of the optional features that get and put handle
+	// under the lock is in use; see replain. It keeps the plain pool
+	// as cheap as the bare ring.
+	plain bool
+
+	// capacity of the pool
+	size int
+
+	// the free queue is either a ring of pointers into arr or, for
+	// compact pools, a ring of indices into arr.
+	q       []*T
+	idx     []int32
+	compact bool
+
+	name string
+
+	// construction time only: run init hooks in parallel and the
+	// memory limit for NewChecked
+	parInit  bool
+	memLimit uint64
+
+	// per-slot tables requested by WithIdleTracking and
+	// WithCheckoutTiming; they're allocated by sideTables once the
+	// options are applied, so NewChecked can vet their size first.
+	wantIdle     bool
+	wantCheckout bool
+
+	// true if the pool was created by NewQueue
+	queue bool
+
+	// hand out the most recently returned object; see WithLRU
+	lru bool
+
+	// signalled when an object is returned to the pool; waiters is
+	// the number of goroutines blocked on it and availWaiters those
+	// that are in WaitAvail.
+	cond         *sync.Cond
+	waiters      int
+	availWaiters int
+
+	// signalled when an object is taken out of the pool; only used by
+	// PutWait callers waiting for room.
+	room        *sync.Cond
+	roomWaiters int
+
+	// set once a feature that Get or Put must check before taking the
+	// lock is in use: Freeze, Use, GetOrFallback or MarkBad.
+	slow atomic.Bool
+
+	// cumulative counters; protected by mu. gets and puts are
+	// updated under the lock but are atomic so they can be sampled
+	// without it.
+	gets      atomic.Int64
+	puts      atomic.Int64
+	misses    uint64
+	resetErrs uint64
+	overflows uint64
+
+	// drop instead of panicking when a Put finds the queue full
+	dropOverflow bool
+
+	// set once GetOrFallback hands out an object of the fallback
+	// allocator, after which Put drops foreign objects; fallbacks is
+	// the number of such objects.
+	fellBack  atomic.Bool
+	fallbacks atomic.Uint64
+
+	// the number of fallback objects of a zero sized type that are
+	// still out; protected by mu. See dropFallback.
+	zeroFalls int
+
+	// scrub the objects on Reset
+	scrubReset bool
+
+	// number of recent Puts checked for duplicates and the number of
+	// duplicates ignored; see WithDedupWindow
+	dedup int
+	dups  uint64
+
+	// optional hook called on every Put
+	reset func(*T, uint32) error
+
+	// optional hook to release the resources of an object
+	cleanup func(*T)
+
+	// the per-object init of NewWithInit or NewIface; used to
+	// rebuild poisoned objects
+	init func(*T)
+
+	// optional constructor of the objects that replace discarded ones;
+	// see WithReplacement
+	replace func() T
+
+	// optional estimator of the out of line bytes of an object
+	sizer func(*T) int64
+
+	// time of the last Put of every slot; only allocated by
+	// WithIdleTracking.
+	idle []time.Time
+
+	// time of the last Get of every checked out slot and the running
+	// aggregates of checkout durations; see WithCheckoutTiming
+	checkout []time.Time
+	holds    uint64
+	holdSum  time.Duration
+	holdMax  time.Duration
+
+	// the goroutine started by StartLeakReaper and whether it takes
+	// leaked objects back; see WithLeakReclaim
+	reaper       *leakReaper
+	reclaimLeaks bool
+
+	// pool that takes objects when the free queue is full; see
+	// SetSpillover
+	spill atomic.Pointer[Pool[T]]
+
+	// callbacks registered via WhenIdle
+	idleFns []func()
+
+	// availability thresholds registered via OnThreshold
+	lows []threshold
+
+	// signalled when the pool is exhausted; see PressureSignal
+	pressure chan struct{}
+
+	// the latency budget of blocking Gets, the callback for exceeding
+	// it and when it last fired; see WithSlowGetThreshold
+	slowGet  time.Duration
+	onSlow   func(waited time.Duration)
+	lastSlow time.Time
+
+	// the limit of Gets between two moments the pool is idle, the
+	// count since the last one and the alert for exceeding it; see
+	// WithMaxOutstanding
+	maxOut    int
+	sinceIdle int
+	tripped   bool
+	outAlert  func(gets int)
+
+	// samples of the in-use count by decile of capacity; only
+	// allocated by WithUsageHistogram
+	hist []int
+
+	// optional event logger; see SetLogger
+	logger atomic.Pointer[Logger]
+
+	// called when an object from BorrowWithDeadline is held too long
+	overdue func(x *T, stack []byte)
+
+	// checked out slots; only allocated when debugging is enabled
+	owned bitset
+
+	// current epoch and the state for GetEpoch callers
+	epoch uint64
+	ep    *epochState
+
+	// tags of objects obtained via GetTagged; allocated on first use
+	tags map[int]string
+
+	// slots marked via MarkBad and their count; nbad is atomic so that
+	// Put can test for it without the lock.
+	bad          bitset
+	nbad         atomic.Int32
+	badRefreshes uint64
+	discards     uint64
+
+	// Get wrapped in the middleware registered via Use; nil if there
+	// is none.
+	mw  atomic.Pointer[GetFunc[T]]
+	mws []func(GetFunc[T]) GetFunc[T]
+
+	// set once by Freeze; fnext is the lock-free rotor of Get and
+	// GetCopy on a frozen pool.
+	frozen atomic.Bool
+	fnext  atomic.Uint64
+
+	// slots that were ever handed out by Get and their count; the
+	// bitset is allocated on first Get.
+	touched  bitset
+	ntouched int
+
+	// operation log; see RecordOps
+	recording bool
+	ops       []Op
+
+	// slots returned via PutPoison; allocated on first use
+	poison         bitset
+	poisonRebuilds uint64
+
 	arr []T
-}
 
-// New creates a new pool of 'sz' objects of type 'T'
-func New[T any](sz int) *Pool[T] {
-	arr := make([]T, sz)
-	q := make([]*T, sz)
+	// the objects of a pool created by FromFactory, which has no
+	// backing array, and their slot indices. Neither is modified after
+	// construction, so objIdx can be read without the lock.
+	objs   []*T
+	objIdx map[*T]int
 
-	// now enq pointers to each elem
-	for i := range arr {
-		q[i] = &arr[i]
-	}
+	// arr published for lock-free ownership checks; see owns()
+	backing atomic.Pointer[[]T]
+}
+
+// New creates a new pool of 'sz' objects of type 'T' configured
+// with the given options.
+func New[T any](sz int, opts ...Option[T]) *Pool[T] {
+	return newPool(make([]T, sz), opts...)
+}
 
+// newPool creates a pool whose backing array is 'arr'
+func newPool[T any](arr []T, opts ...Option[T]) *Pool[T] {
 	// the pool starts off as "full"; it is full of
 	// unconsumed objects
+	sz := len(arr)
 	o := &Pool[T]{
 		rd:    0,
 		wr:    0,
 		avail: sz,
-		q:     q,
+		size:  sz,
+		epoch: 1,
 		arr:   arr,
 	}
+
+	o.cond = sync.NewCond(&o.mu)
+	o.room = sync.NewCond(&o.mu)
+
+	// options pick the representation of the free queue; so they
+	// must be applied before it's built.
+	for _, fn := range opts {
+		fn(o)
+	}
+
+	// now enq pointers to each elem
+	o.sideTables()
+	o.fill()
+	o.replain()
+	o.backing.Store(&o.arr)
 	return o
 }
 
-// Reset resets the pool to its initial state; all extant allocations
-// are reclaimed for reuse.
-func (p *Pool[T]) Reset() {
+// sideTables allocates the per-slot state requested by the options
+func (p *Pool[T]) sideTables() {
+	if p.wantIdle {
+		p.idle = newIdle(p.size)
+	}
+	if p.wantCheckout {
+		p.checkout = make([]time.Time, p.size)
+	}
+}
+
+// NewIface creates a new pool of 'sz' objects of type 'T' where each
+// slot is constructed by calling 'ctor' once, at construction time. It
+// is meant for pooled types whose zero value is useless, e.g. interface
+// types or types that need per-object setup.
+func NewIface[T any](sz int, ctor func() T, opts ...Option[T]) *Pool[T] {
+	p := New[T](sz, opts...)
+	p.init = func(x *T) {
+		*x = ctor()
+	}
+	for i := range p.arr {
+		p.arr[i] = ctor()
+	}
+	return p
+}
+
+// NewWithInit creates a new pool of 'sz' objects of type 'T' and calls
+// 'init' exactly once on every object before returning. If the pool is
+// configured WithParallelInit, the calls are spread across GOMAXPROCS
+// goroutines; NewWithInit returns only after every call completes.
+func NewWithInit[T any](sz int, init func(*T), opts ...Option[T]) *Pool[T] {
+	p := New[T](sz, opts...)
+	p.init = init
+	p.initAll(init)
+	return p
+}
+
+// NewWithInitErr creates a new pool of 'sz' objects of type 'T' and
+// calls 'init' on every object in order; it stops at the first error.
+// If a cleanup func is configured via WithCleanup, it is called on every
+// object that was successfully initialized before the error is returned.
+// This lets pools of resource backed objects (e.g. file descriptors)
+// fail fast at startup instead of handing out half-initialized objects.
+// The init calls are always serial.
+func NewWithInitErr[T any](sz int, init func(*T) error, opts ...Option[T]) (*Pool[T], error) {
+	p := New[T](sz, opts...)
+	for i := range p.arr {
+		if err := init(&p.arr[i]); err != nil {
+			if p.cleanup != nil {
+				for j := 0; j < i; j++ {
+					p.cleanup(&p.arr[j])
+				}
+			}
+			return nil, fmt.Errorf("objpool: init of object %d: %w", i, err)
+		}
+	}
+	return p, nil
+}
+
+// initAll calls 'init' on every element of the backing array
+func (p *Pool[T]) initAll(init func(*T)) {
+	n := runtime.GOMAXPROCS(0)
+	if !p.parInit || n == 1 || len(p.arr) < 2 {
+		for i := range p.arr {
+			init(&p.arr[i])
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+
+	// each goroutine handles a contiguous chunk of the array
+	chunk := (len(p.arr) + n - 1) / n
+	for i := 0; i < len(p.arr); i += chunk {
+		v := p.arr[i:min(i+chunk, len(p.arr))]
+
+		wg.Add(1)
+		go func(v []T) {
+			defer wg.Done()
+			for j := range v {
+				init(&v[j])
+			}
+		}(v)
+	}
+	wg.Wait()
+}
+
+// Reset resets the pool to its initial state: the free queue is
+// rebuilt in backing array order and the ring indices rewound. Reset
+// is only safe when the pool is idle; it returns ErrInUse without
+// changing anything if any object is checked out. Forcibly reclaiming
+// checked out objects would let their holders Put them back later and
+// double enqueue them.
+//
+// A pool created by NewQueue starts off empty; so Reset discards
+// everything that is enqueued.
+func (p *Pool[T]) Reset() error {
 	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.frozen.Load() {
+		return ErrFrozen
+	}
+
+	if p.queue {
+		clear(p.q)
+		p.rd = 0
+		p.wr = 0
+		p.avail = 0
+		p.debugf("reset")
+		return nil
+	}
+
+	if p.avail != p.size {
+		return ErrInUse
+	}
+
+	if p.scrubReset {
+		p.scrub()
+	}
+
 	p.rd = 0
 	p.wr = 0
-	p.avail = len(p.q)
-	for i := 0; i < len(p.q); i++ {
-		p.q[i] = &p.arr[i]
+	p.avail = p.size
+	p.fill()
+	if p.owned != nil {
+		p.owned = newBitset(p.nobjs())
 	}
-	p.mu.Unlock()
+	if p.lows != nil {
+		p.crossings()
+	}
+	if p.hist != nil {
+		clear(p.hist)
+	}
+	if p.maxOut > 0 {
+		p.rearmOut()
+	}
+	p.ep = nil
+	p.replain()
+	p.debugf("reset")
+	return nil
+}
+
+// ResetPreserveOrder is like Reset but rebuilds the free queue in the
+// reverse order in which objects were last Put, rather than in backing
+// array order: the most recently returned objects, whose memory is most
+// likely still in the CPU caches, are handed out first after the reset.
+// The free queue of an idle pool is already in Put order, so no extra
+// recency tracking is needed; GetCopy, ImportState and Reset itself
+// reorder the queue and the order after them is the queue order. Pools
+// created WithLRU already hand out the most recently returned object
+// first and keep that order.
+//
+// Like Reset, it returns ErrInUse without changing anything if any
+// object is checked out. A queue created by NewQueue has no reuse
+// order and can't be reset this way.
+func (p *Pool[T]) ResetPreserveOrder() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.frozen.Load():
+		return ErrFrozen
+	case p.queue:
+		return fmt.Errorf("objpool: can't reorder a queue")
+	case p.avail != p.size:
+		return ErrInUse
+	}
+
+	if p.scrubReset {
+		p.scrub()
+	}
+
+	// newest first
+	v := make([]*T, 0, p.size)
+	for i, j := 0, p.wr; i < p.size; i++ {
+		j = p.dec(j)
+		v = append(v, p.at(j))
+	}
+
+	// an LRU pool takes from behind wr; so the newest goes last
+	p.rd = 0
+	p.wr = 0
+	for i, x := range v {
+		if p.lru {
+			i = p.size - 1 - i
+		}
+		p.setAt(i, x)
+	}
+	if p.owned != nil {
+		p.owned = newBitset(p.nobjs())
+	}
+	p.ep = nil
+	p.replain()
+	p.debugf("reset in recency order")
+	return nil
 }
 
 // Get returns a single object from the pool. It returns nil if the pool
-// has exhausted its capacity.
+// has exhausted its capacity. Middleware registered via Use wraps Get.
 func (p *Pool[T]) Get() *T {
+	if p.slow.Load() {
+		if p.frozen.Load() {
+			return p.frozenNext()
+		}
+		if mw := p.mw.Load(); mw != nil {
+			return (*mw)()
+		}
+	}
+	return p.getNow()
+}
+
+// getNow is Get without any middleware
+func (p *Pool[T]) getNow() *T {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	if p.avail == 0 {
+		p.miss()
 		return nil
 	}
+	if !p.plain && p.maxOut > 0 && p.refuse() {
+		return nil
+	}
+	return p.get()
+}
 
-	var rd int
-	rd, p.rd = p.rd, p.inc(p.rd)
-	p.avail -= 1
-	return p.q[rd]
+// GetCopy returns a copy of the value of the next free object; the
+// object itself is immediately rotated to the tail of the free queue, so
+// it never stays checked out and successive calls walk through the free
+// objects. This suits pools of preconfigured template objects where
+// callers only need a snapshot of a template. The returned value is
+// independent of the pool: mutating it doesn't affect the pooled object
+// (though reference fields such as slices and maps are shared). It
+// returns false if the pool has no free objects.
+func (p *Pool[T]) GetCopy() (T, bool) {
+	if p.frozen.Load() {
+		if x := p.frozenNext(); x != nil {
+			return *x, true
+		}
+		var zero T
+		return zero, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var v T
+	if p.avail == 0 {
+		return v, false
+	}
+
+	x := p.at(p.rd)
+	v = *x
+	p.setAt(p.wr, x)
+	p.rd = p.inc(p.rd)
+	p.wr = p.inc(p.wr)
+	return v, true
+}
+
+// GetN returns up to 'n' objects from the pool without blocking. If the
+// pool is empty it returns an empty, non-nil slice so callers can range
+// over the result without a nil check.
+func (p *Pool[T]) GetN(n int) []*T {
+	if n <= 0 {
+		return []*T{}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == 0 {
+		p.miss()
+		return []*T{}
+	}
+	if p.maxOut > 0 && p.refuse() {
+		return []*T{}
+	}
+	return p.take(make([]*T, 0, min(n, p.avail)), n)
+}
+
+// MustGet is like Get but panics if the pool is empty; it is meant for
+// code paths where exhausting the pool is a programming error.
+func (p *Pool[T]) MustGet() *T {
+	x := p.Get()
+	if x == nil {
+		msg := fmt.Sprintf("%s: pool exhausted (cap %d)", p.label(), p.size)
+		panic(msg)
+	}
+	return x
 }
 
-// Put returns the object back to the pool
+// Put returns the object back to the pool. If a reset hook is
+// configured, it is run before the object is enqueued; an object
+// that fails its reset is replaced by a fresh object (see
+// WithReplacement).
 func (p *Pool[T]) Put(x *T) {
+	p.PutFlags(x, 0)
+}
+
+// PutFlags returns the object back to the pool and passes 'flags' to
+// the reset hook configured via WithResetFlags.
+func (p *Pool[T]) PutFlags(x *T, flags uint32) {
+	if p.slow.Load() && (p.frozen.Load() || p.fallback(x)) {
+		return
+	}
+
+	var bad bool
+	if p.reset != nil || p.slow.Load() {
+		bad = p.runReset(x, flags)
+	}
+	if s := p.putOne(x, bad); s != nil {
+		s.spillIn(x)
+	}
+}
+
+// putOne enqueues 'x' and returns the spillover pool if 'x' must go
+// there instead.
+func (p *Pool[T]) putOne(x *T, bad bool) *Pool[T] {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if !p.plain && p.checkPut(x) {
+		return nil
+	}
+
 	// in a well behaved system, we should never have a queue full
 	// condition. It can only happen if we have a double free somewhere!
-	if p.avail == len(p.q) {
-		msg := fmt.Sprintf("%T: unexpected q-full", p)
-		panic(msg)
+	if p.avail == p.size {
+		return p.full(x, 0)
+	}
+
+	p.put(x, bad)
+	return nil
+}
+
+// PutN returns all the objects in 'v' back to the pool under a single
+// acquisition of the lock. The reset hook runs on each object before the
+// lock is taken. Blocked waiters are woken one per returned object
+// rather than all at once: returning N objects to a pool with M > N
+// waiters wakes exactly N of them, so a large batch doesn't cause a
+// thundering herd of waiters that then go back to sleep.
+func (p *Pool[T]) PutN(v []*T) {
+	if p.frozen.Load() {
+		return
+	}
+	if p.fellBack.Load() {
+		v = p.ownedOnly(v)
 	}
 
-	var wr int
-	wr, p.wr = p.wr, p.inc(p.wr)
+	bad := make([]bool, len(v))
+	for i, x := range v {
+		bad[i] = p.runReset(x, 0)
+	}
+
+	var spilled []*T
+	s := func() *Pool[T] {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		var s *Pool[T]
+		for i, x := range v {
+			if !p.plain && p.checkPut(x) {
+				continue
+			}
+			if p.avail == p.size {
+				if t := p.full(x, 0); t != nil {
+					s = t
+					spilled = append(spilled, x)
+				}
+				continue
+			}
+			p.put(x, bad[i])
+		}
+		return s
+	}()
+
+	for _, x := range spilled {
+		s.spillIn(x)
+	}
+}
+
+// runReset runs the reset hook if one is configured and replaces 'x'
+// with a fresh object (see renew) if the hook fails. It returns true
+// if 'x' was replaced. Objects marked bad are refreshed instead of
+// reset.
+func (p *Pool[T]) runReset(x *T, flags uint32) bool {
+	if p.slow.Load() && p.nbad.Load() > 0 && p.takeBad(x) {
+		p.refresh(x)
+		return false
+	}
+
+	if p.reset == nil {
+		return false
+	}
+
+	if err := p.reset(x, flags); err != nil {
+		p.renew(x)
+		return true
+	}
+	return false
+}
+
+// put enqueues 'x' in the free queue; the caller must hold the lock and
+// ensure the queue isn't full. 'bad' is true if 'x' failed its reset.
+func (p *Pool[T]) put(x *T, bad bool) {
+	p.setAt(p.wr, x)
+	p.wr = p.inc(p.wr)
 	p.avail += 1
-	p.q[wr] = x
+	p.puts.Store(p.puts.Load() + 1)
+	p.epoch += 1
+
+	if !p.plain || bad {
+		p.putHooks(x, bad)
+	}
+	if p.waiters > 0 {
+		p.wakeup()
+	}
+}
+
+// checkPut runs the checks of the optional features before 'x' is
+// enqueued; it returns true if 'x' must be ignored. The caller must
+// hold the lock.
+func (p *Pool[T]) checkPut(x *T) bool {
+	if p.dedup > 0 && p.isDup(x) {
+		return true
+	}
+	if p.owned != nil {
+		p.trackPut(x)
+	}
+	return false
+}
+
+// putHooks runs the optional features after 'x' was enqueued; the
+// caller must hold the lock.
+func (p *Pool[T]) putHooks(x *T, bad bool) {
+	if p.ep != nil {
+		p.epochPut(x)
+	}
+	if p.tags != nil {
+		p.untag(x)
+	}
+	if p.idle != nil {
+		p.stamp(x)
+	}
+	if p.checkout != nil {
+		p.checkin(x)
+	}
+	if bad {
+		p.resetErrs += 1
+	}
+	if p.recording {
+		p.record(OpPut, x)
+	}
+	if p.idleFns != nil && p.avail == p.size {
+		p.fireIdle()
+	}
+	if p.maxOut > 0 && p.avail == p.size {
+		p.rearmOut()
+	}
+	if p.lows != nil {
+		p.crossings()
+	}
+	if p.hist != nil {
+		p.sample()
+	}
+}
+
+// ForEach calls 'fn' on every object in the pool exactly once,
+// regardless of whether it is free or checked out. The pool lock is
+// held for the duration of the iteration so 'fn' must not call back
+// into the pool.
+//
+// Objects that are checked out may be in concurrent use by other
+// goroutines while 'fn' runs on them; ForEach is meant for shutdown
+// and bulk administration (e.g. closing every connection) where the
+// caller knows no such concurrent use is in progress.
+func (p *Pool[T]) ForEach(fn func(*T)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := 0; i < p.nobjs(); i++ {
+		fn(p.obj(i))
+	}
 }
 
 // Avail returns number of free objects in the pool
@@ -103,25 +782,193 @@ func (p *Pool[T]) Avail() int {
 	return n
 }
 
+// TryAvail is a non-blocking Avail for monitoring code on hot paths:
+// if the pool lock is contended it returns (0, false) immediately
+// instead of waiting for it. A false result means the pool couldn't be
+// sampled right now, not that it's empty.
+func (p *Pool[T]) TryAvail() (int, bool) {
+	if !p.mu.TryLock() {
+		return 0, false
+	}
+	n := p.avail
+	p.mu.Unlock()
+	return n, true
+}
+
+// TotalGets returns the cumulative number of objects handed out by the
+// pool, including those handed out in batches. It doesn't take the pool
+// lock and is meant for cheap sampling by monitoring code.
+func (p *Pool[T]) TotalGets() int64 {
+	return p.gets.Load()
+}
+
+// TotalPuts returns the cumulative number of objects returned to the
+// pool. Like TotalGets it doesn't take the pool lock.
+func (p *Pool[T]) TotalPuts() int64 {
+	return p.puts.Load()
+}
+
+// Balance returns the number of Gets minus the number of Puts over the
+// lifetime of the pool. For a fixed pool it equals InUse, but it is read
+// from the lock-free counters and is cheap to sample. A negative value
+// would mean more objects were returned than handed out, i.e. a double
+// free or a Put of a foreign object. It can't be observed: the Put that
+// would make the balance negative is precisely the one that finds the
+// free queue full. That Put panics in objpool_debug builds, unless the
+// pool was created WithDropOnOverflow; otherwise it is dropped and
+// counted in Stats.Overflows. For a queue created by NewQueue the
+// balance is the negated length of the queue.
+func (p *Pool[T]) Balance() int {
+	// load puts first: a concurrent Get/Put pair between the loads can
+	// only make the balance appear larger, never spuriously negative.
+	puts := p.puts.Load()
+	return int(p.gets.Load() - puts)
+}
+
+// Cap returns the capacity of the pool
+func (p *Pool[T]) Cap() int {
+	return p.size
+}
+
+// InUse returns number of objects currently checked out of the pool
+func (p *Pool[T]) InUse() int {
+	p.mu.Lock()
+	n := p.size - p.avail
+	p.mu.Unlock()
+	return n
+}
+
 // String returns a string description of the pool
 func (p *Pool[T]) String() string {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	var s string
-	if p.avail == len(p.q) {
+	if p.avail == p.size {
 		s = "[FULL] "
 	} else if p.avail == 0 {
 		s = "[EMPTY] "
 	}
 
+	if p.name != "" {
+		s = p.name + " " + s
+	}
+
 	return fmt.Sprintf("<%T %scap=%d, free=%d wr=%d rd=%d",
-		p, s, len(p.q), p.avail, p.wr, p.rd)
+		p, s, p.size, p.avail, p.wr, p.rd)
+}
+
+// wakeup wakes waiters after an object is returned; the caller must
+// hold the lock.
+func (p *Pool[T]) wakeup() {
+	if p.availWaiters > 0 {
+		p.cond.Broadcast()
+	} else {
+		p.cond.Signal()
+	}
+}
+
+// get dequeues the next free object; the caller must hold the lock
+// and ensure the pool is not empty. A frozen pool hands out the next
+// shared object instead.
+func (p *Pool[T]) get() *T {
+	if !p.plain {
+		return p.getHooked()
+	}
+
+	rd := p.rd
+	p.rd = p.inc(rd)
+	p.avail -= 1
+	p.gets.Store(p.gets.Load() + 1)
+
+	x := p.at(rd)
+	if p.ntouched < p.size {
+		p.touch(x)
+	}
+	if p.roomWaiters > 0 {
+		p.room.Signal()
+	}
+	return x
+}
+
+// getHooked is get for a pool that uses some of the optional features
+func (p *Pool[T]) getHooked() *T {
+	if p.frozen.Load() {
+		return p.frozenNext()
+	}
+
+	var rd int
+	if p.lru {
+		p.wr = p.dec(p.wr)
+		rd = p.wr
+	} else {
+		rd, p.rd = p.rd, p.inc(p.rd)
+	}
+	p.avail -= 1
+	p.gets.Store(p.gets.Load() + 1)
+
+	x := p.at(rd)
+	p.getHooks(x)
+	if p.roomWaiters > 0 {
+		p.room.Signal()
+	}
+	return x
+}
+
+// getHooks runs the optional features after 'x' was dequeued; the
+// caller must hold the lock.
+func (p *Pool[T]) getHooks(x *T) {
+	if p.ntouched < p.size {
+		p.touch(x)
+	}
+	if p.checkout != nil {
+		p.checkoutStart(x)
+	}
+	if p.lows != nil {
+		p.crossings()
+	}
+	if p.hist != nil {
+		p.sample()
+	}
+	if p.maxOut > 0 {
+		p.sinceIdle += 1
+	}
+	if p.pressure != nil && p.avail == 0 {
+		p.exhausted()
+	}
+	if p.owned != nil {
+		p.trackGet(x)
+	}
+	if p.poison != nil {
+		p.rebuild(x)
+	}
+	if p.recording {
+		p.record(OpGet, x)
+	}
+}
+
+// replain recomputes whether get and put can skip the optional
+// features; it must be called, with the lock held, whenever one of
+// them is turned on or off.
+func (p *Pool[T]) replain() {
+	p.plain = !p.lru && p.dedup == 0 &&
+		p.checkout == nil && p.lows == nil && p.hist == nil &&
+		p.maxOut == 0 && p.pressure == nil && p.owned == nil &&
+		p.poison == nil && !p.recording && p.ep == nil &&
+		p.tags == nil && p.idle == nil && p.idleFns == nil &&
+		!p.frozen.Load()
 }
 
 func (p *Pool[T]) inc(i int) int {
-	if i = i + 1; i >= len(p.q) {
+	if i = i + 1; i >= p.size {
 		i = 0
 	}
 	return i
 }
+
+func (p *Pool[T]) dec(i int) int {
+	if i == 0 {
+		i = p.size
+	}
+	return i - 1
+}
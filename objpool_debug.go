@@ -0,0 +1,37 @@
+// objpool_debug.go - panic on a Put to a full queue
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+//go:build objpool_debug
+
+package objpool
+
+// The objpool_debug build tag selects how Put handles an object that
+// finds the free queue full, which in a fixed pool can only be due to a
+// double free or the Put of a foreign object:
+//
+//	go test -tags objpool_debug ./...
+//
+// With the tag, such a Put panics with a DoubleFreeError (unless the
+// pool was created with WithDropOnOverflow); this is meant for debug
+// and test builds. Without the tag, the object is silently dropped and
+// counted in Stats.Overflows. The choice is made at compile time so
+// release builds carry no code for building the diagnostics. Note that
+// the check for a full queue itself remains in both: enqueuing into a
+// full ring would corrupt it.
+//
+// Ownership tracking via SetDebug is independent of the tag and always
+// panics on a double free.
+
+// overflow handles a Put of 'x' that found the free queue full; the
+// caller must hold the lock.
+func (p *Pool[T]) overflow(x *T) {
+	err := p.doubleFree(x, p.slot(x))
+	if !p.dropOverflow {
+		panic(err)
+	}
+
+	p.overflows += 1
+	p.warnf("objpool: dropped object: %s", err)
+}
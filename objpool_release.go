@@ -0,0 +1,18 @@
+// objpool_release.go - drop a Put to a full queue
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+//go:build !objpool_debug
+
+package objpool
+
+// overflow handles a Put of 'x' that found the free queue full; the
+// caller must hold the lock. Release builds drop 'x'; see
+// objpool_debug.go.
+func (p *Pool[T]) overflow(x *T) {
+	p.overflows += 1
+	if p.dropOverflow {
+		p.warnf("objpool: dropped object: %s", p.doubleFree(x, p.slot(x)))
+	}
+}
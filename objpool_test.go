@@ -1,7 +1,11 @@
 package objpool_test
 
 import (
+	"errors"
 	"github.com/opencoff/go-objpool"
+	"math"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -49,3 +53,731 @@ func TestAll(t *testing.T) {
 
 	assert(o.Avail() == size, "size: exp %d, saw %d", size, o.Avail())
 }
+
+func TestResetHook(t *testing.T) {
+	assert := newAsserter(t)
+
+	type buf struct {
+		n   int
+		bad bool
+	}
+
+	reset := func(b *buf) error {
+		if b.bad {
+			return errors.New("bad buf")
+		}
+		b.n = 0
+		return nil
+	}
+
+	o := objpool.New[buf](2, objpool.WithReset(reset))
+
+	a := o.Get()
+	a.n = 42
+	o.Put(a)
+	assert(a.n == 0, "reset: exp 0, saw %d", a.n)
+
+	b := o.Get()
+	b.n = 7
+	b.bad = true
+	o.Put(b)
+	assert(b.n == 0 && !b.bad, "bad obj not replaced: %+v", *b)
+
+	s := o.Stats()
+	assert(s.ResetErrors == 1, "reset errs: exp 1, saw %d", s.ResetErrors)
+	assert(o.Avail() == 2, "avail: exp 2, saw %d", o.Avail())
+}
+
+func TestForEach(t *testing.T) {
+	assert := newAsserter(t)
+
+	size := 4
+	o := objpool.New[int](size)
+
+	a := o.Get()
+	*a = 100
+
+	seen := make(map[*int]bool)
+	o.ForEach(func(x *int) {
+		assert(!seen[x], "%p: visited twice", x)
+		seen[x] = true
+		*x += 1
+	})
+
+	assert(len(seen) == size, "foreach: exp %d, saw %d", size, len(seen))
+	assert(seen[a], "checked out obj not visited")
+	assert(*a == 101, "checked out obj: exp 101, saw %d", *a)
+}
+
+func TestPutFlags(t *testing.T) {
+	assert := newAsserter(t)
+
+	const (
+		dirtyA uint32 = 1 << iota
+		dirtyB
+	)
+
+	type obj struct {
+		a, b int
+	}
+
+	reset := func(x *obj, fl uint32) error {
+		if fl&dirtyA != 0 {
+			x.a = 0
+		}
+		if fl&dirtyB != 0 {
+			x.b = 0
+		}
+		return nil
+	}
+
+	o := objpool.New[obj](1, objpool.WithResetFlags(reset))
+
+	x := o.Get()
+	x.a, x.b = 1, 2
+	o.PutFlags(x, dirtyA)
+	assert(x.a == 0 && x.b == 2, "partial reset: %+v", *x)
+
+	x = o.Get()
+	o.Put(x)
+	assert(x.b == 2, "zero flags reset b: %+v", *x)
+
+	x = o.Get()
+	o.PutFlags(x, dirtyA|dirtyB)
+	assert(x.a == 0 && x.b == 0, "full reset: %+v", *x)
+}
+
+func TestResetPreserveOrder(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+	v := o.GetN(4)
+	err := o.ResetPreserveOrder()
+	assert(errors.Is(err, objpool.ErrInUse), "reset of busy pool: %v", err)
+
+	o.Put(v[2])
+	o.Put(v[0])
+	o.Put(v[3])
+	o.Put(v[1])
+	err = o.ResetPreserveOrder()
+	assert(err == nil, "reset: %v", err)
+
+	w := o.GetN(4)
+	exp := []*int{v[1], v[3], v[0], v[2]}
+	for i := range exp {
+		assert(w[i] == exp[i], "get %d: exp %p, saw %p", i, exp[i], w[i])
+	}
+
+	// the same order for a pool that hands out the newest first
+	o = objpool.New[int](4, objpool.WithLRU[int]())
+	v = o.GetN(4)
+	o.Put(v[2])
+	o.Put(v[0])
+	o.Put(v[3])
+	o.Put(v[1])
+	err = o.ResetPreserveOrder()
+	assert(err == nil, "lru reset: %v", err)
+
+	w = o.GetN(4)
+	exp = []*int{v[1], v[3], v[0], v[2]}
+	for i := range exp {
+		assert(w[i] == exp[i], "lru get %d: exp %p, saw %p", i, exp[i], w[i])
+	}
+
+	q := objpool.NewQueue[int](2)
+	assert(q.ResetPreserveOrder() != nil, "queue reordered")
+}
+
+func TestInitialFree(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4, objpool.WithInitialFree[int](1))
+	assert(o.Avail() == 1 && o.InUse() == 3, "avail %d, inuse %d", o.Avail(), o.InUse())
+	assert(errors.Is(o.Reset(), objpool.ErrInUse), "reset of partially filled pool")
+
+	var all []*int
+	o.ForEach(func(x *int) {
+		all = append(all, x)
+	})
+
+	a := o.Get()
+	assert(a == all[0], "exp first object, saw %p", a)
+	assert(o.Get() == nil, "withheld object handed out")
+
+	for _, x := range all[1:] {
+		o.Put(x)
+	}
+	o.Put(a)
+	assert(o.Avail() == 4, "avail: exp 4, saw %d", o.Avail())
+	assert(o.Reset() == nil, "reset of full pool")
+
+	o = objpool.New[int](2, objpool.WithInitialFree[int](5))
+	assert(o.Avail() == 2, "clamp: avail %d", o.Avail())
+}
+
+func TestReset(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](3)
+	a := o.Get()
+	b := o.Get()
+
+	err := o.Reset()
+	assert(err == objpool.ErrInUse, "reset busy pool: exp ErrInUse, saw %v", err)
+	assert(o.Avail() == 1, "avail: exp 1, saw %d", o.Avail())
+
+	o.Put(b)
+	o.Put(a)
+	err = o.Reset()
+	assert(err == nil, "reset idle pool: %v", err)
+	assert(o.Avail() == 3, "avail: exp 3, saw %d", o.Avail())
+}
+
+// hammer Get/Put/Reset concurrently; a successful Reset must never
+// lead to a double enqueue (which would panic on q-full).
+func TestResetConcurrent(t *testing.T) {
+	assert := newAsserter(t)
+
+	const size = 8
+	o := objpool.New[int](size)
+	o.SetDebug(true)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5000; j++ {
+				if x := o.Get(); x != nil {
+					o.Put(x)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				o.Reset()
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+	assert(o.Avail() == size, "avail: exp %d, saw %d", size, o.Avail())
+}
+
+func TestConfig(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](5)
+	c := o.Config()
+	assert(c.Cap == 5, "cap: exp 5, saw %d", c.Cap)
+	assert(!c.HasReset && !c.Debug, "unexpected config: %+v", c)
+
+	reset := func(*int) error { return nil }
+	o = objpool.New[int](2, objpool.WithReset(reset))
+	o.SetDebug(true)
+	c = o.Config()
+	assert(c.HasReset && c.Debug && !c.LRU, "unexpected config: %+v", c)
+
+	o = objpool.New[int](2, objpool.WithLRU[int]())
+	assert(o.Config().LRU, "lru not reported: %+v", o.Config())
+}
+
+func TestReplace(t *testing.T) {
+	assert := newAsserter(t)
+
+	type conn struct {
+		id int
+	}
+
+	o := objpool.New[conn](2)
+
+	a := o.Get()
+	a.id = 1
+
+	fresh := &conn{id: 2}
+	err := o.Replace(a, fresh)
+	assert(err == nil, "replace: %v", err)
+	assert(a.id == 2, "replace: exp 2, saw %d", a.id)
+
+	err = o.Replace(fresh, fresh)
+	assert(err == objpool.ErrForeign, "foreign: exp ErrForeign, saw %v", err)
+
+	o.Put(a)
+	err = o.Replace(a, fresh)
+	assert(err == objpool.ErrNotCheckedOut, "free: exp ErrNotCheckedOut, saw %v", err)
+
+	o.SetDebug(true)
+	b := o.Get()
+	assert(o.Replace(b, fresh) == nil, "debug replace failed")
+	o.Put(b)
+	err = o.Replace(b, fresh)
+	assert(err == objpool.ErrNotCheckedOut, "debug free: exp ErrNotCheckedOut, saw %v", err)
+}
+
+type shape interface {
+	Area() int
+}
+
+type square struct {
+	n int
+}
+
+func (s *square) Area() int {
+	return s.n * s.n
+}
+
+func TestNewIface(t *testing.T) {
+	assert := newAsserter(t)
+
+	var n int
+	ctor := func() shape {
+		n++
+		return &square{n: n}
+	}
+
+	size := 3
+	o := objpool.NewIface[shape](size, ctor)
+	assert(n == size, "ctor: exp %d calls, saw %d", size, n)
+
+	x := o.Get()
+	assert(x != nil && *x != nil, "expected constructed obj")
+	assert((*x).Area() == 1, "area: exp 1, saw %d", (*x).Area())
+	o.Put(x)
+}
+
+func TestNewWithInit(t *testing.T) {
+	assert := newAsserter(t)
+
+	for _, par := range []bool{false, true} {
+		var opts []objpool.Option[int]
+		if par {
+			opts = append(opts, objpool.WithParallelInit[int]())
+		}
+
+		size := 1001
+		o := objpool.NewWithInit[int](size, func(x *int) {
+			*x += 1
+		}, opts...)
+
+		n := 0
+		o.ForEach(func(x *int) {
+			assert(*x == 1, "par %v: init ran %d times", par, *x)
+			n++
+		})
+		assert(n == size, "par %v: exp %d objs, saw %d", par, size, n)
+	}
+}
+
+func TestNewChecked(t *testing.T) {
+	assert := newAsserter(t)
+
+	o, err := objpool.NewChecked[int](4)
+	assert(err == nil && o.Cap() == 4, "new: %v", err)
+
+	_, err = objpool.NewChecked[int](-1)
+	assert(err != nil, "negative size accepted")
+
+	_, err = objpool.NewChecked[[1024]byte](1024, objpool.WithMemLimit[[1024]byte](1024*1024))
+	assert(err != nil, "mem limit not enforced")
+
+	// absurd size: make() panics and NewChecked must recover
+	_, err = objpool.NewChecked[[1 << 20]byte](math.MaxInt32)
+	assert(err != nil, "huge size accepted")
+
+	// the limit counts the per-slot tables of the options and is
+	// checked before they're allocated
+	lim := objpool.WithMemLimit[int](1000 * 20)
+	_, err = objpool.NewChecked[int](1000, lim)
+	assert(err == nil, "pool within limit: %v", err)
+	_, err = objpool.NewChecked[int](1000, lim, objpool.WithCheckoutTiming[int]())
+	assert(err != nil, "checkout table not counted")
+	_, err = objpool.NewChecked[int](math.MaxInt, objpool.WithIdleTracking[int](),
+		objpool.WithMemLimit[int](1<<30))
+	assert(err != nil, "huge idle table accepted")
+
+	// the size in bytes saturates instead of wrapping around
+	_, err = objpool.NewChecked[[1 << 20]byte](math.MaxInt, objpool.WithMemLimit[[1 << 20]byte](1))
+	assert(err != nil, "overflowing size accepted")
+}
+
+func TestCompact(t *testing.T) {
+	assert := newAsserter(t)
+
+	size := 3
+	o := objpool.New[int](size, objpool.WithCompact[int]())
+	p := objpool.New[int](size)
+
+	// both representations must hand out objects in the same order
+	var a, b []*int
+	for i := 0; i < size; i++ {
+		a = append(a, o.Get())
+		b = append(b, p.Get())
+	}
+	assert(o.Get() == nil, "compact: exp nil from exhausted pool")
+
+	o.Put(a[2])
+	o.Put(a[0])
+	p.Put(b[2])
+	p.Put(b[0])
+
+	x, y := o.Get(), p.Get()
+	assert(x == a[2] && y == b[2], "compact: FIFO order broken")
+
+	var foreign int
+	mustPanic(t, func() { o.Put(&foreign) })
+	assert(o.Avail() == 1, "compact: foreign put changed avail: %d", o.Avail())
+
+	o.Put(x)
+	o.Put(a[1])
+	assert(o.Avail() == size, "compact: exp %d, saw %d", size, o.Avail())
+	assert(o.Reset() == nil, "compact: reset failed")
+}
+
+func TestNewWithInitErr(t *testing.T) {
+	assert := newAsserter(t)
+
+	type fd struct {
+		n    int
+		open bool
+	}
+
+	var opened int
+	init := func(x *fd) error {
+		if opened == 3 {
+			return errors.New("out of fds")
+		}
+		opened++
+		x.n = opened
+		x.open = true
+		return nil
+	}
+
+	var closed int
+	cleanup := func(x *fd) {
+		assert(x.open, "cleanup of uninitialized obj %d", x.n)
+		x.open = false
+		closed++
+	}
+
+	o, err := objpool.NewWithInitErr[fd](5, init, objpool.WithCleanup(cleanup))
+	assert(o == nil && err != nil, "exp init error")
+	assert(closed == 3, "cleanup: exp 3, saw %d", closed)
+
+	opened = 0
+	o, err = objpool.NewWithInitErr[fd](3, init)
+	assert(err == nil && o.Avail() == 3, "new: %v", err)
+
+	x := o.Get()
+	assert(x.open, "handed out uninitialized obj")
+}
+
+func TestMarkBad(t *testing.T) {
+	assert := newAsserter(t)
+
+	type conn struct {
+		id int
+	}
+
+	var cleaned int
+	resets := 0
+	o := objpool.New[conn](2,
+		objpool.WithCleanup(func(*conn) { cleaned++ }),
+		objpool.WithReset(func(*conn) error { resets++; return nil }))
+
+	a := o.Get()
+	a.id = 5
+	assert(o.MarkBad(a) == nil, "markbad failed")
+	assert(o.MarkBad(&conn{}) == objpool.ErrForeign, "foreign obj marked")
+
+	o.Put(a)
+	assert(a.id == 0, "bad obj not refreshed: %d", a.id)
+	assert(cleaned == 1 && resets == 0, "cleanup %d, resets %d", cleaned, resets)
+	assert(o.Stats().BadRefreshes == 1, "bad refreshes: %d", o.Stats().BadRefreshes)
+	assert(o.MarkBad(a) == objpool.ErrNotCheckedOut, "free obj marked")
+
+	// the mark is cleared after the refresh
+	b := o.Get()
+	c := o.Get()
+	o.Put(b)
+	o.Put(c)
+	assert(cleaned == 1 && resets == 2, "cleanup %d, resets %d", cleaned, resets)
+}
+
+func TestGetN(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](3)
+
+	v := o.GetN(2)
+	assert(len(v) == 2, "getn: exp 2, saw %d", len(v))
+
+	w := o.GetN(5)
+	assert(len(w) == 1, "getn: exp 1, saw %d", len(w))
+
+	x := o.GetN(1)
+	assert(x != nil && len(x) == 0, "getn on empty pool: %v", x)
+
+	z := o.GetN(0)
+	assert(z != nil && len(z) == 0, "getn(0): %v", z)
+
+	r := mustPanic(t, func() { o.MustGet() })
+	assert(strings.Contains(r.(string), "exhausted"), "wrong panic: %v", r)
+
+	o.Put(w[0])
+	assert(o.MustGet() == w[0], "mustget: wrong obj")
+}
+
+func TestPutPoison(t *testing.T) {
+	assert := newAsserter(t)
+
+	type obj struct {
+		state string
+	}
+
+	var inits int
+	init := func(x *obj) {
+		inits++
+		x.state = "fresh"
+	}
+
+	o := objpool.NewWithInit[obj](1, init)
+	assert(inits == 1, "inits: exp 1, saw %d", inits)
+
+	x := o.Get()
+	x.state = "wedged"
+	o.PutPoison(x)
+	assert(x.state == "wedged", "poisoned obj rebuilt early")
+
+	y := o.Get()
+	assert(y == x, "exp same slot")
+	assert(y.state == "fresh" && inits == 2, "poisoned obj not rebuilt: %q", y.state)
+	assert(o.Stats().PoisonRebuilds == 1, "rebuilds: %d", o.Stats().PoisonRebuilds)
+
+	// a normal Put doesn't rebuild
+	y.state = "used"
+	o.Put(y)
+	y = o.Get()
+	assert(y.state == "used" && inits == 2, "unpoisoned obj rebuilt")
+	o.Put(y)
+}
+
+func TestMiddleware(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](1)
+
+	var trace []string
+	layer := func(name string) func(objpool.GetFunc[int]) objpool.GetFunc[int] {
+		return func(next objpool.GetFunc[int]) objpool.GetFunc[int] {
+			return func() *int {
+				trace = append(trace, name+">")
+				x := next()
+				trace = append(trace, "<"+name)
+				return x
+			}
+		}
+	}
+
+	o.Use(layer("a"))
+	o.Use(layer("b"))
+
+	x := o.Get()
+	assert(x != nil, "expected obj; got nil")
+
+	got := strings.Join(trace, " ")
+	exp := "a> b> <b <a"
+	assert(got == exp, "order: exp %q, saw %q", exp, got)
+
+	o.Put(x)
+	trace = trace[:0]
+	assert(o.GetTimeout(0) == x, "middleware broke get")
+	assert(len(trace) == 4, "middleware not run: %v", trace)
+}
+
+func TestGetCopy(t *testing.T) {
+	assert := newAsserter(t)
+
+	type tmpl struct {
+		name string
+	}
+
+	names := []string{"a", "b", "c"}
+	i := 0
+	o := objpool.NewWithInit[tmpl](3, func(x *tmpl) {
+		x.name = names[i]
+		i++
+	})
+
+	a, ok := o.GetCopy()
+	assert(ok && a.name == "a", "copy: exp a, saw %q", a.name)
+
+	a.name = "mutated"
+	b, _ := o.GetCopy()
+	c, _ := o.GetCopy()
+	d, _ := o.GetCopy()
+	assert(b.name == "b" && c.name == "c", "copy order: %q %q", b.name, c.name)
+	assert(d.name == "a", "template mutated via copy: %q", d.name)
+	assert(o.Avail() == 3, "avail: exp 3, saw %d", o.Avail())
+
+	o.GetN(3)
+	_, ok = o.GetCopy()
+	assert(!ok, "copy from empty pool")
+}
+
+func TestPutIf(t *testing.T) {
+	assert := newAsserter(t)
+
+	type conn struct {
+		errs int
+	}
+
+	healthy := func(c *conn) bool {
+		return c.errs < 3
+	}
+
+	o := objpool.New[conn](2)
+
+	a := o.Get()
+	a.errs = 1
+	assert(o.PutIf(a, healthy), "healthy obj discarded")
+	assert(a.errs == 1, "retained obj modified")
+
+	b := o.Get()
+	b.errs = 5
+	assert(!o.PutIf(b, healthy), "unhealthy obj retained")
+	assert(b.errs == 0, "discarded slot not replaced")
+	assert(o.Avail() == 2, "capacity not maintained: %d", o.Avail())
+	assert(o.Stats().Discards == 1, "discards: %d", o.Stats().Discards)
+}
+
+func TestReplacement(t *testing.T) {
+	assert := newAsserter(t)
+
+	type conn struct {
+		gen  int
+		open bool
+	}
+
+	var gen int
+	fresh := func() conn {
+		gen++
+		return conn{gen: gen, open: true}
+	}
+
+	failReset := func(c *conn) error {
+		if !c.open {
+			return errors.New("closed")
+		}
+		return nil
+	}
+
+	o := objpool.NewWithInit[conn](1, func(c *conn) {
+		*c = fresh()
+	}, objpool.WithReset(failReset), objpool.WithReplacement(fresh))
+
+	// failed reset
+	x := o.Get()
+	x.open = false
+	o.Put(x)
+	assert(x.open && x.gen == 2, "reset failure: %+v", *x)
+
+	// MarkBad
+	x = o.Get()
+	assert(o.MarkBad(x) == nil, "markbad")
+	o.Put(x)
+	assert(x.gen == 3, "markbad: %+v", *x)
+
+	// PutIf
+	x = o.Get()
+	o.PutIf(x, func(*conn) bool { return false })
+	assert(x.gen == 4, "putif: %+v", *x)
+
+	// PutPoison rebuilds via the replacement, not the init func
+	x = o.Get()
+	o.PutPoison(x)
+	x = o.Get()
+	assert(x.gen == 5, "poison: %+v", *x)
+	o.Put(x)
+
+	// EvictFreeIf
+	n := o.EvictFreeIf(func(*conn) bool { return true })
+	assert(n == 1 && x.gen == 6, "evict: %+v", *x)
+}
+
+func TestSwapBacking(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.NewWithInit[int](3, func(x *int) { *x = 1 })
+
+	x := o.Get()
+	_, err := o.SwapBacking([]int{2, 2, 2})
+	assert(err == objpool.ErrInUse, "swap busy pool: exp ErrInUse, saw %v", err)
+
+	o.Put(x)
+	_, err = o.SwapBacking([]int{2})
+	assert(err != nil, "swap with wrong size accepted")
+
+	old, err := o.SwapBacking([]int{2, 2, 2})
+	assert(err == nil, "swap: %v", err)
+	assert(len(old) == 3 && old[0] == 1, "old backing: %v", old)
+
+	for _, y := range o.GetN(3) {
+		assert(*y == 2, "exp new backing obj, saw %d", *y)
+	}
+
+	// objects of the old array no longer belong to the pool
+	assert(!objpool.PutTo(&old[0], o), "old backing obj accepted")
+}
+
+func TestTakeAllAndReset(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.NewWithInit[int](3, func(x *int) { *x = 1 })
+
+	x := o.Get()
+	v, err := o.TakeAllAndReset()
+	assert(err == nil, "take from busy pool: %v", err)
+	assert(len(v) == 2 && o.Avail() == 2, "took %d, avail %d", len(v), o.Avail())
+
+	// the checked out object stays out and goes back as usual
+	for _, y := range v {
+		assert(y != x && !objpool.PutTo(y, o), "taken obj still owned by the pool")
+		*y = 7
+	}
+	for _, y := range o.GetN(2) {
+		assert(*y == 1, "free obj not reset: %d", *y)
+		o.Put(y)
+	}
+	*x = 5
+	o.Put(x)
+	assert(o.Avail() == 3, "avail: exp 3, saw %d", o.Avail())
+
+	v, err = o.TakeAllAndReset()
+	assert(err == nil, "take: %v", err)
+	assert(len(v) == 3 && o.Avail() == 3, "took %d, avail %d", len(v), o.Avail())
+
+	var saw5 bool
+	for _, y := range v {
+		saw5 = saw5 || *y == 5
+		assert(!objpool.PutTo(y, o), "taken obj still owned by the pool")
+	}
+	assert(saw5, "taken objects aren't the old ones")
+
+	// the fresh objects are distinct and initialized
+	w := o.GetN(3)
+	for _, y := range w {
+		assert(*y == 1, "fresh obj not initialized: %d", *y)
+		for _, z := range v {
+			assert(y != z, "fresh obj aliases a taken one")
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// aliasing.go - stress test that pools never hand out an object twice
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpooltest
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// GetPutter is the part of a pool that VerifyNoAliasing exercises; it
+// is satisfied by objpool.Pool as well as the other pool variants.
+type GetPutter[T any] interface {
+	Get() *T
+	Put(x *T)
+}
+
+// VerifyNoAliasing runs 'workers' goroutines that each do 'iters'
+// rounds of Get and Put on 'p' and fails the test if the pool ever
+// hands out the same object to two callers at once. Every checked out
+// object is recorded until just before it is returned; a Get that
+// returns an object that is still recorded is an aliasing violation.
+// Gets that find the pool exhausted are retried in the next round.
+//
+// It's meant as the canonical correctness test for pool backends and
+// for checking that a pool is safe under the concurrency of the caller.
+func VerifyNoAliasing[T any](t testing.TB, p GetPutter[T], workers, iters int) {
+	t.Helper()
+
+	var out sync.Map
+	var bad atomic.Int64
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iters; i++ {
+				x := p.Get()
+				if x == nil {
+					runtime.Gosched()
+					continue
+				}
+
+				if _, dup := out.LoadOrStore(x, struct{}{}); dup {
+					bad.Add(1)
+					continue
+				}
+
+				// give others a chance to get the same object
+				runtime.Gosched()
+				out.Delete(x)
+				p.Put(x)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := bad.Load(); n > 0 {
+		t.Fatalf("%v: %d objects handed out to two callers at once", p, n)
+	}
+}
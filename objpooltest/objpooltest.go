@@ -0,0 +1,25 @@
+// objpooltest.go - test helpers for code that uses objpool
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+// objpooltest provides helpers for writing tests of code that uses
+// objpool; e.g., catching leaked objects at test teardown.
+package objpooltest
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+// AssertFullyReturned fails the test if any object is still checked
+// out of the pool 'p'. It is meant to be called at teardown:
+//
+//	defer objpooltest.AssertFullyReturned(t, pool)
+func AssertFullyReturned[T any](t testing.TB, p *objpool.Pool[T]) {
+	t.Helper()
+	if n := p.InUse(); n != 0 {
+		t.Fatalf("%s: %d of %d objects not returned", p, n, p.Cap())
+	}
+}
@@ -0,0 +1,95 @@
+package objpooltest_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+	"github.com/opencoff/go-objpool/objpooltest"
+)
+
+// fakeT records failures instead of failing the enclosing test
+type fakeT struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Fatalf(string, ...any) {
+	f.failed = true
+}
+
+func TestAssertFullyReturned(t *testing.T) {
+	o := objpool.New[int](2)
+
+	ft := &fakeT{TB: t}
+	objpooltest.AssertFullyReturned(ft, o)
+	if ft.failed {
+		t.Fatalf("full pool reported as leaking")
+	}
+
+	x := o.Get()
+	objpooltest.AssertFullyReturned(ft, o)
+	if !ft.failed {
+		t.Fatalf("leaked object not reported")
+	}
+
+	o.Put(x)
+	objpooltest.AssertFullyReturned(t, o)
+}
+
+func TestTaggedPool(t *testing.T) {
+	tp := objpooltest.NewTaggedPool(objpool.New[int](2))
+
+	a := tp.Get()
+	b := tp.Get()
+
+	ia, _ := tp.ID(a)
+	ib, _ := tp.ID(b)
+	if ia != 1 || ib != 2 {
+		t.Fatalf("ids: exp 1, 2; saw %d, %d", ia, ib)
+	}
+
+	tp.Put(a)
+	if !tp.Returned(ia) || tp.Returned(ib) {
+		t.Fatalf("returned: %v %v", tp.Returned(ia), tp.Returned(ib))
+	}
+
+	// the reused object gets a new id
+	c := tp.Get()
+	ic, _ := tp.ID(c)
+	if c != a || ic != 3 {
+		t.Fatalf("reuse: exp id 3 for %p, saw %d for %p", a, ic, c)
+	}
+
+	out := tp.Outstanding()
+	if len(out) != 2 || out[0] != ib || out[1] != ic {
+		t.Fatalf("outstanding: %v", out)
+	}
+
+	tp.Put(b)
+	tp.Put(c)
+	if tp.Avail() != 2 {
+		t.Fatalf("avail: exp 2, saw %d", tp.Avail())
+	}
+	objpooltest.AssertFullyReturned(t, tp.Pool())
+}
+
+// aliasing hands out the same object to every caller
+type aliasing struct {
+	x int
+}
+
+func (a *aliasing) Get() *int  { return &a.x }
+func (a *aliasing) Put(x *int) {}
+
+func TestVerifyNoAliasing(t *testing.T) {
+	objpooltest.VerifyNoAliasing[int](t, objpool.New[int](4), 8, 1000)
+	objpooltest.VerifyNoAliasing[int](t, objpool.NewLockFree[int](4), 8, 1000)
+
+	ft := &fakeT{TB: t}
+	objpooltest.VerifyNoAliasing[int](ft, &aliasing{}, 8, 100)
+	if !ft.failed {
+		t.Fatalf("aliasing not detected")
+	}
+}
@@ -0,0 +1,115 @@
+// tagged.go - pool wrapper that stamps every Get with a unique id
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpooltest
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/opencoff/go-objpool"
+)
+
+// TaggedPool wraps a Pool and stamps every object handed out by Get
+// with a unique, monotonically increasing id. Tests can then assert
+// which checkout flowed where and that every checkout was returned.
+// Only Get and Put are wrapped; the other Get variants of the pool
+// would hand out objects without an id, so the pool isn't embedded.
+type TaggedPool[T any] struct {
+	pool *objpool.Pool[T]
+
+	mu   sync.Mutex
+	next uint64
+
+	// id of the current checkout of each object
+	ids map[*T]uint64
+
+	// ids of checkouts that were returned
+	done map[uint64]bool
+}
+
+// NewTaggedPool wraps the pool 'p'
+func NewTaggedPool[T any](p *objpool.Pool[T]) *TaggedPool[T] {
+	return &TaggedPool[T]{
+		pool: p,
+		ids:  make(map[*T]uint64),
+		done: make(map[uint64]bool),
+	}
+}
+
+// Get returns an object from the underlying pool and stamps it with
+// the next id; ids start at 1.
+func (t *TaggedPool[T]) Get() *T {
+	x := t.pool.Get()
+	if x == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	t.next += 1
+	t.ids[x] = t.next
+	t.mu.Unlock()
+	return x
+}
+
+// Put records the return of the checkout of 'x' and returns it to the
+// underlying pool; it panics if 'x' wasn't handed out by Get.
+func (t *TaggedPool[T]) Put(x *T) {
+	t.mu.Lock()
+	id, ok := t.ids[x]
+	if !ok {
+		t.mu.Unlock()
+		panic(fmt.Sprintf("%T: put of %p that isn't checked out", t, x))
+	}
+	delete(t.ids, x)
+	t.done[id] = true
+	t.mu.Unlock()
+
+	t.pool.Put(x)
+}
+
+// Pool returns the underlying pool, e.g. for AssertFullyReturned.
+// Objects must be obtained and returned via the TaggedPool.
+func (t *TaggedPool[T]) Pool() *objpool.Pool[T] {
+	return t.pool
+}
+
+// Avail returns the number of free objects in the underlying pool
+func (t *TaggedPool[T]) Avail() int {
+	return t.pool.Avail()
+}
+
+// ID returns the id of the current checkout of 'x'
+func (t *TaggedPool[T]) ID(x *T) (uint64, bool) {
+	t.mu.Lock()
+	id, ok := t.ids[x]
+	t.mu.Unlock()
+	return id, ok
+}
+
+// Returned returns true if the checkout 'id' was returned
+func (t *TaggedPool[T]) Returned(id uint64) bool {
+	t.mu.Lock()
+	ok := t.done[id]
+	t.mu.Unlock()
+	return ok
+}
+
+// Outstanding returns the ids of all checkouts not yet returned in
+// increasing order.
+func (t *TaggedPool[T]) Outstanding() []uint64 {
+	t.mu.Lock()
+	v := make([]uint64, 0, len(t.ids))
+	for _, id := range t.ids {
+		v = append(v, id)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(v, func(i, j int) bool {
+		return v[i] < v[j]
+	})
+	return v
+}
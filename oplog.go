@@ -0,0 +1,90 @@
+// oplog.go - record the sequence of pool operations for tests
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+)
+
+// OpKind is the kind of a recorded operation
+type OpKind int
+
+const (
+	OpGet OpKind = iota
+	OpPut
+)
+
+// String returns the name of the operation kind
+func (k OpKind) String() string {
+	switch k {
+	case OpGet:
+		return "get"
+	case OpPut:
+		return "put"
+	default:
+		return fmt.Sprintf("OpKind(%d)", int(k))
+	}
+}
+
+// Op is a single recorded operation on the pool
+type Op struct {
+	Kind OpKind
+
+	// Slot is the slot index of the object or -1 if the object isn't
+	// part of the backing array.
+	Slot int
+
+	// Avail is the number of free objects after the operation
+	Avail int
+}
+
+// String returns a string description of the op
+func (o Op) String() string {
+	return fmt.Sprintf("%s(%d) avail=%d", o.Kind, o.Slot, o.Avail)
+}
+
+// RecordOps turns recording of operations on or off. While on, every
+// object handed out or returned - by any Get or Put variant - is
+// appended to an in-memory log that can be retrieved via OpLog; this
+// lets tests assert the exact sequence of pool operations that their
+// code performs. The pool otherwise behaves normally. The log grows
+// without bound while recording is on; use ClearOpLog to reset it.
+// Turning recording off discards the log.
+func (p *Pool[T]) RecordOps(on bool) {
+	p.mu.Lock()
+	p.recording = on
+	if !on {
+		p.ops = nil
+	}
+	p.replain()
+	p.mu.Unlock()
+}
+
+// OpLog returns a copy of the recorded operations
+func (p *Pool[T]) OpLog() []Op {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	v := make([]Op, len(p.ops))
+	copy(v, p.ops)
+	return v
+}
+
+// ClearOpLog discards the recorded operations
+func (p *Pool[T]) ClearOpLog() {
+	p.mu.Lock()
+	p.ops = p.ops[:0]
+	p.mu.Unlock()
+}
+
+// record logs an op on 'x'; the caller must hold the lock.
+func (p *Pool[T]) record(k OpKind, x *T) {
+	p.ops = append(p.ops, Op{
+		Kind:  k,
+		Slot:  p.slot(x),
+		Avail: p.avail,
+	})
+}
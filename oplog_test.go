@@ -0,0 +1,41 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestOpLog(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+	o.Get()
+	o.RecordOps(true)
+
+	a := o.Get()
+	v := o.GetN(2)
+	o.Put(a)
+	o.Put(v[1])
+
+	exp := []objpool.Op{
+		{objpool.OpGet, 1, 2},
+		{objpool.OpGet, 2, 1},
+		{objpool.OpGet, 3, 0},
+		{objpool.OpPut, 1, 1},
+		{objpool.OpPut, 3, 2},
+	}
+
+	ops := o.OpLog()
+	assert(len(ops) == len(exp), "oplog: exp %d ops, saw %d", len(exp), len(ops))
+	for i := range exp {
+		assert(ops[i] == exp[i], "%d: exp %s, saw %s", i, exp[i], ops[i])
+	}
+
+	o.ClearOpLog()
+	assert(len(o.OpLog()) == 0, "oplog not cleared")
+
+	o.RecordOps(false)
+	o.Get()
+	assert(len(o.OpLog()) == 0, "recorded while off")
+}
@@ -0,0 +1,324 @@
+// options.go - construction time options for the pool
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"math"
+	"time"
+)
+
+// Option configures a pool at construction time
+type Option[T any] func(p *Pool[T])
+
+// WithName gives the pool a name that is used in its String()
+// representation and in diagnostics.
+func WithName[T any](name string) Option[T] {
+	return func(p *Pool[T]) {
+		p.name = name
+	}
+}
+
+// WithParallelInit makes NewWithInit run the per-object init across
+// GOMAXPROCS goroutines. Each object is independent, so this speeds up
+// the construction of large pools with expensive init. The init func
+// must be safe to call concurrently on distinct objects.
+func WithParallelInit[T any]() Option[T] {
+	return func(p *Pool[T]) {
+		p.parInit = true
+	}
+}
+
+// WithMemLimit sets the maximum number of bytes that NewChecked may
+// allocate for the pool; see NewChecked for how the size is computed.
+// It has no effect on New.
+func WithMemLimit[T any](n uint64) Option[T] {
+	return func(p *Pool[T]) {
+		p.memLimit = n
+	}
+}
+
+// WithCompact makes the pool keep its free queue as a ring of 32-bit
+// indices into the backing array instead of a ring of pointers; this
+// halves the overhead of the queue on 64-bit platforms. Get and Put
+// behave the same, except that Put panics on an object that isn't part
+// of the backing array. The capacity must fit in an int32 and types of
+// size zero always use a ring of pointers. For very large pools, the
+// bitmap free list of Ordered is smaller still.
+func WithCompact[T any]() Option[T] {
+	return func(p *Pool[T]) {
+		if p.size <= math.MaxInt32 {
+			p.compact = true
+		}
+	}
+}
+
+// WithDropOnOverflow makes Put drop an object that finds the free queue
+// full and log a warning via the Logger of the pool, if it has one; the
+// drops are counted in Stats.Overflows. In builds with the objpool_debug
+// tag, such a Put otherwise panics; in release builds the object is
+// always dropped and this option only adds the warning. See
+// objpool_debug.go.
+//
+// The pool doesn't grow to absorb the object: when the queue is full
+// every object of the backing array is already free, so the object is
+// either a duplicate - enqueuing it again would hand it out to two
+// callers at once - or doesn't belong to the pool. And the backing
+// array can't be reallocated, since checked out objects point into it.
+// Dropping is the only policy that keeps the ring consistent.
+func WithDropOnOverflow[T any]() Option[T] {
+	return func(p *Pool[T]) {
+		p.dropOverflow = true
+	}
+}
+
+// WithOverdueHook sets the callback that BorrowWithDeadline calls when
+// a borrowed object isn't returned within its deadline. It is passed
+// the object and the stack of the goroutine that borrowed it. Without
+// a hook, overdue borrows are logged via the Logger of the pool.
+func WithOverdueHook[T any](fn func(x *T, stack []byte)) Option[T] {
+	return func(p *Pool[T]) {
+		p.overdue = fn
+	}
+}
+
+// WithIdleTracking makes the pool record the time of every Put so that
+// OldestIdle can report how long free objects have been idle. It costs
+// a clock read on every Put.
+func WithIdleTracking[T any]() Option[T] {
+	return func(p *Pool[T]) {
+		p.wantIdle = true
+	}
+}
+
+// WithCheckoutTiming makes the pool record the time of every Get and
+// accumulate how long objects are held until they're Put back, for
+// AvgCheckoutDuration and MaxCheckoutDuration. It costs a clock read on
+// every Get and Put.
+func WithCheckoutTiming[T any]() Option[T] {
+	return func(p *Pool[T]) {
+		p.wantCheckout = true
+	}
+}
+
+// WithLeakReclaim makes the reaper started by StartLeakReaper return
+// every leaked object to the pool, as Put would, right before reporting
+// it; LeakInfo.Reclaimed is then true. This is dangerous: if the holder
+// is merely slow rather than gone, it keeps using an object that has
+// been handed out to someone else, and its eventual Put is a double
+// free. Only use it when holders that exceed the maximum age are known
+// to be dead. The reset hook runs with the pool lock held and must not
+// call back into the pool.
+func WithLeakReclaim[T any]() Option[T] {
+	return func(p *Pool[T]) {
+		p.reclaimLeaks = true
+	}
+}
+
+// WithReplacement sets the constructor of the objects that take the
+// place of discarded ones. A fixed pool can't drop a slot; so whenever
+// it discards an object - one that failed its reset hook, was marked
+// via MarkBad, rejected by PutIf, returned via PutPoison or evicted by
+// EvictFreeIf - it overwrites the slot in place. Without a replacement
+// constructor the slot gets a zero value (or, for poisoned objects, is
+// rebuilt by the init func of NewWithInit or NewIface), which leaves
+// types whose zero value is useless half-initialized. 'fn' may be called
+// with the pool lock held and must not call back into the pool.
+func WithReplacement[T any](fn func() T) Option[T] {
+	return func(p *Pool[T]) {
+		p.replace = fn
+	}
+}
+
+// WithInitialFree makes the pool start with only the first 'n' objects
+// of the backing array free instead of all of them; it suits pools that
+// are populated by Puts over time, e.g. as objects are set up lazily.
+// The other objects are considered checked out: Avail starts at 'n'
+// and InUse at Cap-n. Put returns them, or any other object for pools
+// that aren't compact, to the pool as usual; ForEach visits them, so
+// callers can collect them and Put each once it is ready. The capacity
+// doesn't change: a Put only overflows once Cap objects are free, and
+// Reset returns ErrInUse until the pool has been filled up, after which
+// it makes every object free. 'n' is clamped to [0, Cap].
+func WithInitialFree[T any](n int) Option[T] {
+	return func(p *Pool[T]) {
+		p.avail = min(max(n, 0), p.size)
+		if p.size > 0 {
+			p.wr = p.avail % p.size
+		}
+	}
+}
+
+// WithUsageHistogram makes the pool sample the number of checked out
+// objects on every Get and Put for UsageHistogram. It costs a division
+// on every Get and Put.
+func WithUsageHistogram[T any]() Option[T] {
+	return func(p *Pool[T]) {
+		p.hist = make([]int, histBuckets)
+	}
+}
+
+// WithLRU makes Get hand out the most recently returned object instead
+// of the one that has been free the longest: the free queue is used as
+// a stack. A small working set of objects then stays busy (and warm),
+// while the others go cold at the far end of the queue, which is what
+// connection pools want so that idle connections can be pruned. So it
+// pairs with idle eviction: the least recently used objects are exactly
+// those that OldestIdle reports and whose idle time grows, so an
+// EvictFreeIf pass that checks idle times (see WithIdleTracking) prunes
+// them without disturbing the hot set. Under sustained load every
+// object stays in use and nothing ages out. GetCopy still rotates the
+// oldest object to the tail of the queue.
+func WithLRU[T any]() Option[T] {
+	return func(p *Pool[T]) {
+		p.lru = true
+	}
+}
+
+// WithMaxOutstanding is a safety valve against runaway leaks: it makes
+// Get refuse to hand out objects once 'n' objects have been handed out
+// since the pool was last idle, i.e. since the last moment when every
+// object was back in the pool (or since it was created or Reset). The
+// first refusal calls 'alert', in its own goroutine, with the number of
+// Gets since the pool was idle; the refusals stop, and the alert
+// re-arms, once every object has been returned. Refused calls of Get and
+// GetN return nothing, and the blocking variants wait as if the pool
+// were empty; the other Get variants are not affected.
+//
+// The heuristic is that objects that circulate many times while the
+// pool never drains are being handed around without proper accounting;
+// a leak storm then exhausts the pool for good, and refusing early
+// surfaces it before that. It misfires on pools that are legitimately
+// never idle: 'n' must be well above the number of Gets that a normal
+// busy period between two idle moments makes, and pools that are never
+// idle in normal operation shouldn't use it at all. Start with a large
+// value and lower it while watching the alert.
+func WithMaxOutstanding[T any](n int, alert func(gets int)) Option[T] {
+	return func(p *Pool[T]) {
+		p.maxOut = max(n, 0)
+		p.outAlert = alert
+	}
+}
+
+// WithSlowGetThreshold makes the blocking Get variants (GetTimeout,
+// GetDeadline, GetContext, GetContextTimed, GetJittered and GetBatch)
+// call 'onSlow', in its own goroutine, when they wait longer than 'd'
+// for an object; 'waited' is how long the call waited, including calls
+// that gave up when their context was done. This surfaces pool-induced
+// latency to callers that track SLOs without their timing every Get.
+// For GetBatch, only the wait for the first object counts, not the
+// linger. The callback is debounced: it fires at most once per 'd', so that
+// sustained exhaustion doesn't flood it. Get and the other non-blocking
+// variants never wait and never fire it.
+func WithSlowGetThreshold[T any](d time.Duration, onSlow func(waited time.Duration)) Option[T] {
+	return func(p *Pool[T]) {
+		p.slowGet = max(d, 0)
+		p.onSlow = onSlow
+	}
+}
+
+// WithScrub makes Reset overwrite every object with its zero value as
+// Scrub does, for pools that hold sensitive data.
+func WithScrub[T any]() Option[T] {
+	return func(p *Pool[T]) {
+		p.scrubReset = true
+	}
+}
+
+// WithDedupWindow makes Put and its variants check whether the object
+// is among the last 'n' objects returned to the pool and ignore it with
+// a warning if it is; the ignored Puts are counted in Stats.Duplicates.
+// This guards against a common kind of double free, where a tight loop
+// Puts the same object twice in quick succession, and turns the crash
+// into a log line. It's much cheaper than ownership tracking via
+// SetDebug, but only catches a duplicate while the first Put is still
+// among the 'n' most recent ones that haven't been handed out again.
+func WithDedupWindow[T any](n int) Option[T] {
+	return func(p *Pool[T]) {
+		p.dedup = max(n, 0)
+	}
+}
+
+// WithReset sets a hook that is called on every object returned to
+// the pool via Put. If the hook returns an error, the object is
+// deemed unusable: it is overwritten with a fresh object (see
+// WithReplacement) before being put back in rotation, and the failure
+// is counted in Stats.ResetErrors.
+func WithReset[T any](fn func(*T) error) Option[T] {
+	return func(p *Pool[T]) {
+		p.reset = func(x *T, _ uint32) error {
+			return fn(x)
+		}
+	}
+}
+
+// WithResetFlags is like WithReset except the hook also receives the
+// flags passed to PutFlags; this lets callers that know how an object
+// was used request a partial or conditional reset. Put passes zero
+// flags.
+func WithResetFlags[T any](fn func(*T, uint32) error) Option[T] {
+	return func(p *Pool[T]) {
+		p.reset = fn
+	}
+}
+
+// WithCleanup sets a hook that releases the resources held by an
+// object; e.g., NewWithInitErr calls it on the objects that were
+// initialized before a failure.
+func WithCleanup[T any](fn func(*T)) Option[T] {
+	return func(p *Pool[T]) {
+		p.cleanup = fn
+	}
+}
+
+// WithSizer sets a func that returns the number of bytes referenced
+// by an object beyond its own size, e.g. the capacity of a buffer; it
+// is used by EstimatedBytes. Like ForEach, the sizer runs on every
+// backing object under the pool lock, including objects that are
+// checked out; so it should only read fields that the holders don't
+// modify.
+func WithSizer[T any](fn func(*T) int64) Option[T] {
+	return func(p *Pool[T]) {
+		p.sizer = fn
+	}
+}
+
+// PoolConfig describes how a pool was configured. It reports the
+// presence of hooks but not the hooks themselves.
+type PoolConfig struct {
+	// Name is the name given via WithName
+	Name string
+
+	// Cap is the capacity of the pool
+	Cap int
+
+	// HasReset is true if a reset hook was configured
+	HasReset bool
+
+	// HasCleanup is true if a cleanup hook was configured
+	HasCleanup bool
+
+	// Debug is true if ownership tracking is enabled
+	Debug bool
+
+	// LRU is true if the pool was created WithLRU
+	LRU bool
+}
+
+// Config returns the configuration of the pool
+func (p *Pool[T]) Config() PoolConfig {
+	p.mu.Lock()
+	c := PoolConfig{
+		Name:     p.name,
+		Cap:      p.size,
+		HasReset: p.reset != nil,
+		Debug:    p.owned != nil,
+		LRU:      p.lru,
+
+		HasCleanup: p.cleanup != nil,
+	}
+	p.mu.Unlock()
+	return c
+}
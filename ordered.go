@@ -0,0 +1,140 @@
+// ordered.go - fixed pool that always hands out the lowest free slot
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"math/bits"
+	"sync"
+	"unsafe"
+)
+
+// Ordered is a fixed pool of objects of type 'T' that ignores the
+// order of returns: Get always hands out the free object with the
+// lowest slot index in the backing array. So a given sequence of Get
+// and Put calls returns the same slots in the same order every time,
+// regardless of scheduling, and after a Reset the pool hands out slots
+// 0, 1, 2, ... again. This determinism is meant for reproducible
+// simulations and tests rather than for throughput: Get scans a bitset
+// of free slots, which costs O(Cap/64) in the worst case.
+//
+// The bitset is also the most compact free list in the package: it
+// costs one bit per slot, against 4 bytes for a compact Pool and 8 bytes
+// for a Pool on 64-bit platforms. For a pool of a million small
+// objects that is 128KiB instead of 4MiB or 8MiB, which makes Ordered
+// the choice for very large pools on a memory budget (see
+// BenchmarkNewMem and BenchmarkGetPutLarge for the tradeoff). Since
+// only the lowest free word is searched and the search resumes where
+// the last one stopped, the scan is cheap unless the free slots are
+// sparse and far apart.
+//
+// Get returns nil when the pool is empty; Put panics on a double free
+// or on an object that doesn't belong to the pool.
+type Ordered[T any] struct {
+	mu sync.Mutex
+
+	// free slots and their count; no word below lo has a free slot
+	free  bitset
+	avail int
+	lo    int
+
+	arr []T
+}
+
+// NewOrdered creates a new ordered pool of 'sz' objects of type 'T';
+// T must not be zero sized.
+func NewOrdered[T any](sz int) *Ordered[T] {
+	var zero T
+	if unsafe.Sizeof(zero) == 0 {
+		panic(fmt.Sprintf("objpool: ordered pool of zero sized %T", zero))
+	}
+
+	p := &Ordered[T]{
+		arr: make([]T, sz),
+	}
+	p.fill()
+	return p
+}
+
+// Get returns the free object with the lowest slot index or nil if the
+// pool is empty.
+func (p *Ordered[T]) Get() *T {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == 0 {
+		return nil
+	}
+
+	for p.free[p.lo] == 0 {
+		p.lo++
+	}
+
+	i := p.lo*64 + bits.TrailingZeros64(p.free[p.lo])
+	p.free.clr(i)
+	p.avail -= 1
+	return &p.arr[i]
+}
+
+// Put returns the object back to the pool
+func (p *Ordered[T]) Put(x *T) {
+	i := slotOf(p.arr, x)
+	if i < 0 {
+		panic(fmt.Sprintf("%T: %p doesn't belong to the pool", p, x))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.free.isset(i) {
+		panic(fmt.Sprintf("%T: double free of %p (slot %d)", p, x, i))
+	}
+
+	p.free.set(i)
+	p.avail += 1
+	p.lo = min(p.lo, i/64)
+}
+
+// Reset marks every object as free; like Pool.Reset it returns ErrInUse
+// without changing anything if any object is checked out.
+func (p *Ordered[T]) Reset() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail != len(p.arr) {
+		return ErrInUse
+	}
+	p.fill()
+	return nil
+}
+
+// Avail returns number of free objects in the pool
+func (p *Ordered[T]) Avail() int {
+	p.mu.Lock()
+	n := p.avail
+	p.mu.Unlock()
+	return n
+}
+
+// Cap returns the capacity of the pool
+func (p *Ordered[T]) Cap() int {
+	return len(p.arr)
+}
+
+// String returns a string description of the pool
+func (p *Ordered[T]) String() string {
+	return fmt.Sprintf("<%T cap=%d, free=%d>", p, len(p.arr), p.Avail())
+}
+
+// fill marks every slot as free
+func (p *Ordered[T]) fill() {
+	p.free = newBitset(len(p.arr))
+	for i := range p.arr {
+		p.free.set(i)
+	}
+	p.avail = len(p.arr)
+	p.lo = 0
+}
@@ -0,0 +1,47 @@
+package objpool_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestOrdered(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.NewOrdered[int](130)
+	assert(o.Avail() == 130 && o.Cap() == 130, "avail: %d", o.Avail())
+
+	v := make([]*int, 130)
+	for i := range v {
+		v[i] = o.Get()
+		assert(v[i] != nil, "%d: nil", i)
+		if i > 0 {
+			assert(uintptr(unsafe.Pointer(v[i])) > uintptr(unsafe.Pointer(v[i-1])),
+				"%d: slots out of order", i)
+		}
+	}
+	assert(o.Get() == nil, "pool not exhausted")
+
+	// returns in any order; gets in ascending slot order
+	o.Put(v[129])
+	o.Put(v[70])
+	o.Put(v[3])
+	assert(o.Get() == v[3], "exp slot 3")
+	assert(o.Get() == v[70], "exp slot 70")
+	assert(o.Get() == v[129], "exp slot 129")
+
+	assert(o.Reset() == objpool.ErrInUse, "reset of a busy pool")
+	for i := len(v) - 1; i >= 0; i-- {
+		o.Put(v[i])
+	}
+	assert(o.Reset() == nil, "reset")
+	for i := 0; i < 5; i++ {
+		assert(o.Get() == v[i], "after reset: exp slot %d", i)
+	}
+
+	mustPanic(t, func() { o.Put(v[10]) })
+	var foreign int
+	mustPanic(t, func() { o.Put(&foreign) })
+}
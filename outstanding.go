@@ -0,0 +1,31 @@
+// outstanding.go - refuse Gets during a suspected leak storm
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// refuse returns true if Get must refuse to hand out objects because
+// the limit of WithMaxOutstanding was exceeded; the first refusal fires
+// the alert. The caller must hold the lock.
+func (p *Pool[T]) refuse() bool {
+	if p.sinceIdle < p.maxOut {
+		return false
+	}
+
+	if !p.tripped {
+		p.tripped = true
+		p.debugf("refusing Get after %d Gets without being idle", p.sinceIdle)
+		if p.outAlert != nil {
+			go p.outAlert(p.sinceIdle)
+		}
+	}
+	return true
+}
+
+// rearmOut restarts the count of WithMaxOutstanding once the pool is
+// idle; the caller must hold the lock.
+func (p *Pool[T]) rearmOut() {
+	p.sinceIdle = 0
+	p.tripped = false
+}
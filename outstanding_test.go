@@ -0,0 +1,79 @@
+package objpool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestMaxOutstanding(t *testing.T) {
+	assert := newAsserter(t)
+
+	alerts := make(chan int, 4)
+	o := objpool.New[int](2, objpool.WithMaxOutstanding[int](3, func(n int) {
+		alerts <- n
+	}))
+
+	// one object stays out while the other circulates
+	a := o.Get()
+	for i := 0; i < 2; i++ {
+		x := o.Get()
+		assert(x != nil, "get %d refused", i)
+		o.Put(x)
+	}
+
+	assert(o.Get() == nil, "get not refused")
+	assert(len(o.GetN(1)) == 0, "getn not refused")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := o.GetContext(ctx)
+	assert(err != nil, "blocking get not refused")
+
+	select {
+	case n := <-alerts:
+		assert(n == 3, "alert: exp 3, saw %d", n)
+	case <-time.After(time.Second):
+		t.Fatalf("no alert")
+	}
+
+	// the alert fires once per trip
+	o.Get()
+	select {
+	case n := <-alerts:
+		t.Fatalf("second alert: %d", n)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// a fully returned pool re-arms
+	o.Put(a)
+	x := o.Get()
+	assert(x != nil, "get refused after the pool was idle")
+}
+
+func TestMaxOutstandingBatch(t *testing.T) {
+	assert := newAsserter(t)
+
+	alerts := make(chan int, 4)
+	o := objpool.New[int](8, objpool.WithMaxOutstanding[int](3, func(n int) {
+		alerts <- n
+	}))
+
+	// a batch is capped at the remaining allowance
+	a := o.Get()
+	v := o.GetN(8)
+	assert(len(v) == 2, "getn: exp 2, saw %d", len(v))
+
+	select {
+	case n := <-alerts:
+		assert(n == 3, "alert: exp 3, saw %d", n)
+	case <-time.After(time.Second):
+		t.Fatalf("no alert")
+	}
+
+	o.Put(a)
+	o.PutN(v)
+	b := o.GetBatch(context.Background(), 8, 0)
+	assert(len(b) == 3, "getbatch: exp 3, saw %d", len(b))
+}
@@ -0,0 +1,27 @@
+//go:build objpool_debug
+
+package objpool_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestDoubleFreeQueueFull(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](2, objpool.WithName[int]("full"))
+	a := o.Get()
+	o.Put(a)
+
+	// without debug tracking, only a full queue catches the double free
+	r := mustPanic(t, func() { o.Put(a) })
+	df, ok := r.(*objpool.DoubleFreeError)
+	assert(ok, "wrong panic: %v", r)
+	assert(strings.Contains(df.Pool, "full"), "pool: exp full, saw %s", df.Pool)
+	assert(df.Slot == 0, "slot: exp 0, saw %d", df.Slot)
+	assert(df.Avail == 2 && df.Cap == 2, "ring state: %+v", df)
+	assert(strings.Contains(df.Error(), "q-full"), "wrong message: %s", df)
+}
@@ -0,0 +1,26 @@
+//go:build !objpool_debug
+
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestDoubleFreeQueueFull(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](2)
+	a := o.Get()
+	o.Put(a)
+
+	// release builds silently drop the duplicate
+	o.Put(a)
+	st := o.Stats()
+	assert(st.Overflows == 1, "overflows: exp 1, saw %d", st.Overflows)
+	assert(st.Avail == 2, "avail: exp 2, saw %d", st.Avail)
+
+	x, y := o.Get(), o.Get()
+	assert(x != nil && y != nil && x != y, "ring corrupted")
+}
@@ -0,0 +1,38 @@
+// pressure.go - edge triggered signal of exhaustion
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// PressureSignal returns a channel that receives a value whenever the
+// pool goes from having free objects to having none, so that upstream
+// producers can throttle, e.g. by selecting on it next to their work
+// queue for admission control. Every call returns the same channel.
+//
+// The signal is edge triggered, not level triggered: it fires on the
+// Get that takes the last free object, not for as long as the pool
+// stays empty, and not at all for Gets that find the pool empty. It
+// never blocks Get: the channel has a buffer of one, and a signal that
+// arrives while one is pending is dropped. So a receiver learns that
+// the pool was exhausted at some point since it last looked, and
+// should check Avail to learn whether it still is.
+func (p *Pool[T]) PressureSignal() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pressure == nil {
+		p.pressure = make(chan struct{}, 1)
+		p.replain()
+	}
+	return p.pressure
+}
+
+// exhausted sends the signal of PressureSignal without blocking; the
+// caller must hold the lock.
+func (p *Pool[T]) exhausted() {
+	select {
+	case p.pressure <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,43 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestPressureSignal(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](2)
+	ch := o.PressureSignal()
+	assert(ch == o.PressureSignal(), "channel not reused")
+
+	pending := func() bool {
+		select {
+		case <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	a := o.Get()
+	assert(!pending(), "signal while objects are free")
+
+	b := o.Get()
+	assert(pending(), "no signal on exhaustion")
+
+	// staying empty doesn't signal again
+	o.Get()
+	assert(!pending(), "signal while empty")
+
+	// signals coalesce and never block Get
+	for i := 0; i < 3; i++ {
+		o.Put(a)
+		a = o.Get()
+	}
+	assert(pending(), "no signal")
+	assert(!pending(), "signals not coalesced")
+	o.Put(b)
+}
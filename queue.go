@@ -0,0 +1,92 @@
+// queue.go - use the ring as a bounded MPMC queue
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"context"
+	"sync"
+)
+
+// NewQueue creates a bounded, concurrency-safe FIFO queue of up to
+// 'sz' objects. It reuses the ring of the pool but inverts its initial
+// state: the queue starts empty, has no backing array and the caller
+// supplies the objects via Enqueue. Get and Put work as well; Get is a
+// dequeue and Put an enqueue that treats a full queue like a double
+// free (see objpool_debug.go).
+func NewQueue[T any](sz int) *Pool[T] {
+	o := &Pool[T]{
+		epoch: 1,
+		queue: true,
+		size:  sz,
+		q:     make([]*T, sz),
+	}
+
+	o.cond = sync.NewCond(&o.mu)
+	o.room = sync.NewCond(&o.mu)
+	return o
+}
+
+// Enqueue adds 'x' to the tail of the queue; it returns false if
+// the queue is full.
+func (p *Pool[T]) Enqueue(x *T) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == p.size {
+		return false
+	}
+	p.put(x, false)
+	return true
+}
+
+// FillFromChan populates the queue from 'ch', e.g. with connections
+// that are opened asynchronously and delivered over a channel. It reads
+// up to 'n' objects, stopping early when the queue is full or 'ch' is
+// closed, and returns the number of objects that were enqueued. Objects
+// beyond the capacity of the queue are left on the channel. A pool that
+// wasn't created by NewQueue starts out full; FillFromChan returns
+// ErrInvalid for it without reading from 'ch'.
+//
+// FillFromChan blocks while waiting for objects to arrive on 'ch'; the
+// queue lock isn't held while it waits. It is meant for populating the
+// queue at startup: if other goroutines enqueue concurrently and fill
+// the queue after an object was read from 'ch', FillFromChan waits for
+// room rather than lose that object.
+func (p *Pool[T]) FillFromChan(ch <-chan *T, n int) (int, error) {
+	if !p.queue {
+		return 0, ErrInvalid
+	}
+
+	var k int
+	for k < n && p.hasRoom() {
+		x, ok := <-ch
+		if !ok {
+			break
+		}
+		p.PutWait(context.Background(), x)
+		k++
+	}
+	return k, nil
+}
+
+// Dequeue removes and returns the object at the head of the queue; it
+// returns false if the queue is empty.
+func (p *Pool[T]) Dequeue() (*T, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == 0 {
+		return nil, false
+	}
+	return p.get(), true
+}
+
+// hasRoom returns true if the queue isn't full
+func (p *Pool[T]) hasRoom() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.avail < p.size
+}
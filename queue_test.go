@@ -0,0 +1,70 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestQueue(t *testing.T) {
+	assert := newAsserter(t)
+
+	size := 3
+	q := objpool.NewQueue[int](size)
+	assert(q.Avail() == 0, "queue not empty: %d", q.Avail())
+
+	_, ok := q.Dequeue()
+	assert(!ok, "dequeue from empty queue")
+
+	v := []int{10, 20, 30, 40}
+	for i := 0; i < size; i++ {
+		assert(q.Enqueue(&v[i]), "%d: enqueue failed", i)
+	}
+	assert(!q.Enqueue(&v[3]), "enqueue into full queue")
+
+	for i := 0; i < size; i++ {
+		x, ok := q.Dequeue()
+		assert(ok && *x == v[i], "%d: exp %d, saw %v", i, v[i], x)
+	}
+
+	q.Enqueue(&v[0])
+	assert(q.Reset() == nil, "reset failed")
+	assert(q.Avail() == 0, "reset didn't empty queue: %d", q.Avail())
+}
+
+func TestFillFromChan(t *testing.T) {
+	assert := newAsserter(t)
+
+	v := []int{1, 2, 3, 4}
+	ch := make(chan *int, len(v))
+	for i := range v {
+		ch <- &v[i]
+	}
+
+	q := objpool.NewQueue[int](3)
+	n, err := q.FillFromChan(ch, 2)
+	assert(err == nil && n == 2 && q.Avail() == 2, "fill: exp 2, saw %d %v", n, err)
+
+	// stops at capacity and leaves the rest on the channel
+	n, _ = q.FillFromChan(ch, 10)
+	assert(n == 1 && q.Avail() == 3, "fill: exp 1, saw %d", n)
+	assert(len(ch) == 1, "channel: exp 1 left, saw %d", len(ch))
+
+	for i := 0; i < 3; i++ {
+		x, ok := q.Dequeue()
+		assert(ok && *x == v[i], "%d: exp %d, saw %v", i, v[i], x)
+	}
+
+	// stops when the channel is closed
+	close(ch)
+	n, _ = q.FillFromChan(ch, 10)
+	assert(n == 1 && q.Avail() == 1, "fill: exp 1, saw %d", n)
+
+	// a pool is full to begin with and can't be filled
+	o := objpool.New[int](2)
+	ch = make(chan *int, 1)
+	ch <- &v[0]
+	n, err = o.FillFromChan(ch, 1)
+	assert(n == 0 && err == objpool.ErrInvalid, "fill pool: %d %v", n, err)
+	assert(len(ch) == 1, "fill pool read the channel")
+}
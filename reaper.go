@@ -0,0 +1,143 @@
+// reaper.go - periodically report objects that are held too long
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"time"
+)
+
+// LeakInfo describes an object that has been checked out for longer
+// than the maximum age given to StartLeakReaper.
+type LeakInfo[T any] struct {
+	// Obj is the leaked object
+	Obj *T
+
+	// Slot is the slot index of Obj
+	Slot int
+
+	// Age is how long Obj has been checked out
+	Age time.Duration
+
+	// Tag is the tag of Obj if it was obtained via GetTagged
+	Tag string
+
+	// Reclaimed is true if Obj was returned to the pool; see
+	// WithLeakReclaim
+	Reclaimed bool
+}
+
+// minReapInterval bounds the scan interval of tiny maximum ages
+const minReapInterval = time.Millisecond
+
+// leakReaper is the state of the goroutine started by StartLeakReaper
+type leakReaper struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartLeakReaper starts a goroutine that scans the checked out
+// objects every maxAge/2 (but at most every millisecond) and calls
+// 'onLeak', from that goroutine and without the pool lock held, for
+// every object that has been checked out for longer than 'maxAge'; each
+// checkout is reported once. The ages come from the Get timestamps
+// recorded by WithCheckoutTiming, and StartLeakReaper returns an error
+// if the pool wasn't created with it or a reaper is already running. It
+// returns ErrInvalid if 'maxAge' isn't positive. StopLeakReaper stops
+// the goroutine.
+//
+// By default the reaper only reports; it never takes objects back. A
+// pool created WithLeakReclaim returns every leaked object to the pool
+// during the scan that finds it; see there for why that is dangerous.
+//
+// Each scan holds the pool lock for O(Cap) time.
+func (p *Pool[T]) StartLeakReaper(maxAge time.Duration, onLeak func(LeakInfo[T])) error {
+	if maxAge <= 0 {
+		return ErrInvalid
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.checkout == nil:
+		return fmt.Errorf("objpool: leak reaper needs WithCheckoutTiming")
+	case p.reaper != nil:
+		return fmt.Errorf("objpool: leak reaper already running")
+	}
+
+	r := &leakReaper{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	p.reaper = r
+
+	go func() {
+		defer close(r.done)
+
+		t := time.NewTicker(max(maxAge/2, minReapInterval))
+		defer t.Stop()
+
+		// Get time of the checkouts already reported, by slot
+		seen := make(map[int]time.Time)
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-t.C:
+				for _, l := range p.leaks(maxAge, seen) {
+					onLeak(l)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// StopLeakReaper stops the goroutine started by StartLeakReaper and
+// waits for it to exit; it's a no-op if no reaper is running.
+func (p *Pool[T]) StopLeakReaper() {
+	p.mu.Lock()
+	r := p.reaper
+	p.reaper = nil
+	p.mu.Unlock()
+
+	if r != nil {
+		close(r.stop)
+		<-r.done
+	}
+}
+
+// leaks returns the objects checked out for longer than 'maxAge' that
+// aren't in 'seen' and records them there.
+func (p *Pool[T]) leaks(maxAge time.Duration, seen map[int]time.Time) []LeakInfo[T] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var v []LeakInfo[T]
+	t := now()
+	for i, t0 := range p.checkout {
+		if t0.IsZero() {
+			delete(seen, i)
+			continue
+		}
+		if d := t.Sub(t0); d > maxAge && !seen[i].Equal(t0) {
+			seen[i] = t0
+			l := LeakInfo[T]{
+				Obj:  p.obj(i),
+				Slot: i,
+				Age:  d,
+				Tag:  p.tags[i],
+			}
+			if p.reclaimLeaks {
+				p.reclaim(l.Obj)
+				l.Reclaimed = true
+			}
+			v = append(v, l)
+		}
+	}
+	return v
+}
@@ -0,0 +1,112 @@
+package objpool_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestLeakReaper(t *testing.T) {
+	assert := newAsserter(t)
+
+	var clk atomic.Int64
+	t0 := time.Now()
+	objpool.SetClock(func() time.Time {
+		return t0.Add(time.Duration(clk.Load()))
+	})
+	defer objpool.SetClock(nil)
+
+	o := objpool.New[int](3)
+	err := o.StartLeakReaper(time.Second, func(objpool.LeakInfo[int]) {})
+	assert(err != nil, "reaper without checkout timing")
+
+	o = objpool.New[int](3, objpool.WithCheckoutTiming[int]())
+	err = o.StartLeakReaper(0, func(objpool.LeakInfo[int]) {})
+	assert(err == objpool.ErrInvalid, "zero age: %v", err)
+
+	// a tiny age doesn't make a ticker of zero
+	err = o.StartLeakReaper(time.Nanosecond, func(objpool.LeakInfo[int]) {})
+	assert(err == nil, "tiny age: %v", err)
+	time.Sleep(5 * time.Millisecond)
+	o.StopLeakReaper()
+
+	leaks := make(chan objpool.LeakInfo[int], 4)
+	err = o.StartLeakReaper(10*time.Millisecond, func(l objpool.LeakInfo[int]) {
+		// forcibly reclaim
+		o.Put(l.Obj)
+		leaks <- l
+	})
+	assert(err == nil, "start: %v", err)
+	defer o.StopLeakReaper()
+
+	err = o.StartLeakReaper(time.Second, func(objpool.LeakInfo[int]) {})
+	assert(err != nil, "second reaper started")
+
+	a, _ := o.GetTagged("req-1")
+	b := o.Get()
+	o.Put(b)
+
+	clk.Store(int64(time.Hour))
+
+	var l objpool.LeakInfo[int]
+	select {
+	case l = <-leaks:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("leak not reported")
+	}
+	assert(l.Obj == a && l.Tag == "req-1" && l.Age == time.Hour, "leak: %+v", l)
+	assert(!l.Reclaimed, "reclaimed by default")
+
+	// reported once
+	select {
+	case l = <-leaks:
+		t.Fatalf("leak reported again: %+v", l)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert(o.Avail() == 3, "leak not reclaimed: avail %d", o.Avail())
+
+	o.StopLeakReaper()
+	o.StopLeakReaper()
+}
+
+func TestLeakReclaim(t *testing.T) {
+	assert := newAsserter(t)
+
+	var clk atomic.Int64
+	t0 := time.Now()
+	objpool.SetClock(func() time.Time {
+		return t0.Add(time.Duration(clk.Load()))
+	})
+	defer objpool.SetClock(nil)
+
+	var resets atomic.Int64
+	reset := func(*int) error {
+		resets.Add(1)
+		return nil
+	}
+	o := objpool.New[int](3, objpool.WithCheckoutTiming[int](),
+		objpool.WithLeakReclaim[int](), objpool.WithReset(reset))
+
+	leaks := make(chan objpool.LeakInfo[int], 4)
+	err := o.StartLeakReaper(10*time.Millisecond, func(l objpool.LeakInfo[int]) {
+		leaks <- l
+	})
+	assert(err == nil, "start: %v", err)
+	defer o.StopLeakReaper()
+
+	a, _ := o.GetTagged("req-1")
+	clk.Store(int64(time.Hour))
+
+	var l objpool.LeakInfo[int]
+	select {
+	case l = <-leaks:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("leak not reported")
+	}
+	assert(l.Obj == a && l.Reclaimed, "leak: %+v", l)
+	assert(o.Avail() == 3, "leak not reclaimed: avail %d", o.Avail())
+	assert(resets.Load() == 1, "reset: %d", resets.Load())
+	assert(len(o.DumpTags()) == 0, "tag kept: %v", o.DumpTags())
+}
@@ -0,0 +1,96 @@
+// reinterpret.go - view the backing array of a pool as another type
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Reinterpret hands the backing array of 'p' over to a new pool that
+// types the same memory as U instead of T, e.g. to use a pool of raw
+// byte records as a pool of header structs without copying. Go doesn't
+// allow methods with type parameters, so this is a function rather than
+// a method of Pool.
+//
+// U and T must have the same size and alignment, and neither may
+// contain pointers (including slices, strings, maps, interfaces and
+// channels). The garbage collector scans the backing array according to
+// the type it was allocated as; a pointer stored through a U where T
+// has none would be invisible to it, and an integer stored where T has
+// a pointer would look like one. Even so, every rule of unsafe type
+// punning applies: the bytes are reinterpreted as is, so the layout,
+// padding and endianness of both types must agree on what they mean.
+//
+// Two pools handing out the same memory independently would alias
+// objects; so 'p' must be idle, Reinterpret returns ErrInUse if it
+// isn't, and 'p' is left exhausted and must not be used afterwards. It
+// also fails for pools without a backing array, i.e. queues and pools
+// created by FromFactory or Merge, and for frozen pools. The new pool
+// inherits the name of 'p' but none of its hooks, which operate on T.
+func Reinterpret[U, T any](p *Pool[T]) (*Pool[U], error) {
+	var t T
+	var u U
+
+	switch {
+	case unsafe.Sizeof(u) != unsafe.Sizeof(t):
+		return nil, fmt.Errorf("objpool: can't reinterpret %T of size %d as %T of size %d",
+			t, unsafe.Sizeof(t), u, unsafe.Sizeof(u))
+	case unsafe.Alignof(u) != unsafe.Alignof(t):
+		return nil, fmt.Errorf("objpool: can't reinterpret %T of alignment %d as %T of alignment %d",
+			t, unsafe.Alignof(t), u, unsafe.Alignof(u))
+	case !pointerFree(reflect.TypeOf(&t).Elem()):
+		return nil, fmt.Errorf("objpool: can't reinterpret %T: it contains pointers", t)
+	case !pointerFree(reflect.TypeOf(&u).Elem()):
+		return nil, fmt.Errorf("objpool: can't reinterpret as %T: it contains pointers", u)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.queue || p.objs != nil:
+		return nil, fmt.Errorf("objpool: pool has no backing array")
+	case p.frozen.Load():
+		return nil, ErrFrozen
+	case p.avail != p.size:
+		return nil, ErrInUse
+	}
+
+	var arr []U
+	if len(p.arr) > 0 {
+		arr = unsafe.Slice((*U)(unsafe.Pointer(&p.arr[0])), len(p.arr))
+	}
+	o := newPool(arr, WithName[U](p.name))
+
+	// every object now belongs to the new pool
+	p.rd = p.wr
+	p.avail = 0
+	return o, nil
+}
+
+// pointerFree returns true if values of type 't' contain no pointers
+func pointerFree(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return t.Len() == 0 || pointerFree(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !pointerFree(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// uintptr is excluded as well: it is often a disguised pointer
+	return false
+}
@@ -0,0 +1,47 @@
+package objpool_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestReinterpret(t *testing.T) {
+	assert := newAsserter(t)
+
+	type hdr struct {
+		Len  uint32
+		Kind uint32
+	}
+
+	raw := objpool.New[[2]uint32](2)
+	x := raw.Get()
+	_, err := objpool.Reinterpret[hdr](raw)
+	assert(errors.Is(err, objpool.ErrInUse), "reinterpret of busy pool: %v", err)
+
+	x[0], x[1] = 42, 7
+	raw.Put(x)
+	h, err := objpool.Reinterpret[hdr](raw)
+	assert(err == nil, "reinterpret: %v", err)
+	assert(h.Cap() == 2 && h.Avail() == 2, "new pool: %s", h)
+	assert(raw.Avail() == 0 && raw.Get() == nil, "old pool not exhausted")
+
+	var found bool
+	h.ForEach(func(y *hdr) {
+		found = found || (y.Len == 42 && y.Kind == 7)
+	})
+	assert(found, "contents not preserved")
+
+	b := objpool.New[[8]byte](1)
+	_, err = objpool.Reinterpret[uint64](b)
+	assert(err != nil, "alignment mismatch accepted")
+
+	s := objpool.New[[4]byte](1)
+	_, err = objpool.Reinterpret[uint64](s)
+	assert(err != nil, "size mismatch accepted")
+
+	p := objpool.New[uint64](1)
+	_, err = objpool.Reinterpret[*int](p)
+	assert(err != nil, "pointer type accepted")
+}
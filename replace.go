@@ -0,0 +1,49 @@
+// replace.go - replace the contents of a checked out object
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// Replace swaps out the contents of the checked out object 'old' with
+// those of 'new'. The pool hands out pointers into its backing array and
+// those pointers are the identity of the slots; so the pool can't adopt
+// 'new' as a different backing object. Instead the value of '*new' is
+// copied into the slot and 'old' remains the pointer to return via Put.
+// 'new' itself is not retained by the pool.
+//
+// Replace returns ErrForeign if 'old' doesn't point into the backing
+// array and ErrNotCheckedOut if it is currently in the free queue. If
+// ownership tracking is off, the latter check scans the free queue.
+func (p *Pool[T]) Replace(old, new *T) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.slot(old)
+	if i < 0 {
+		return ErrForeign
+	}
+
+	if !p.checkedOut(i, old) {
+		return ErrNotCheckedOut
+	}
+
+	*old = *new
+	return nil
+}
+
+// checkedOut returns true if slot 'i' holding 'x' isn't in the free
+// queue; the caller must hold the lock.
+func (p *Pool[T]) checkedOut(i int, x *T) bool {
+	if p.owned != nil {
+		return p.owned.isset(i)
+	}
+
+	out := true
+	p.freeEach(func(y *T) {
+		if y == x {
+			out = false
+		}
+	})
+	return out
+}
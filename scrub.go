@@ -0,0 +1,45 @@
+// scrub.go - wipe pooled objects that hold sensitive data
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// Scrub overwrites every object of the pool with its zero value, so that
+// sensitive data such as keys or tokens doesn't linger in memory once
+// the pool is torn down. For objects of type []byte, the underlying
+// bytes (up to the capacity of the slice) are zeroed before the slice
+// itself is cleared. Unlike the reset hook, Scrub runs over all objects
+// unconditionally; it's meant for teardown and is also run by Reset on
+// pools created with WithScrub.
+//
+// Scrub only succeeds when the pool is idle and returns ErrInUse
+// otherwise; a frozen pool can't be scrubbed. It is best effort: it
+// can't reach copies of the data made elsewhere, e.g. values copied out
+// by GetCopy, by callers or onto goroutine stacks, nor memory that the
+// objects reference other than byte slices.
+func (p *Pool[T]) Scrub() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.frozen.Load() {
+		return ErrFrozen
+	}
+	if p.avail != p.size {
+		return ErrInUse
+	}
+	p.scrub()
+	return nil
+}
+
+// scrub zeroes every object; the caller must hold the lock.
+func (p *Pool[T]) scrub() {
+	var zero T
+	for i := 0; i < p.nobjs(); i++ {
+		x := p.obj(i)
+		if b, ok := any(x).(*[]byte); ok {
+			clear((*b)[:cap(*b)])
+		}
+		*x = zero
+	}
+}
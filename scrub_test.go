@@ -0,0 +1,36 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestScrub(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.NewWithInit[[]byte](2, func(b *[]byte) {
+		*b = make([]byte, 4, 8)
+	})
+
+	x := o.Get()
+	copy((*x)[:cap(*x)], "secret!!")
+	buf := (*x)[:cap(*x)]
+
+	assert(o.Scrub() == objpool.ErrInUse, "scrubbed a busy pool")
+	o.Put(x)
+
+	assert(o.Scrub() == nil, "scrub")
+	assert(*x == nil, "slice not cleared: %v", *x)
+	for i, c := range buf {
+		assert(c == 0, "byte %d not zeroed: %q", i, buf)
+	}
+
+	// Reset scrubs pools created WithScrub
+	k := objpool.New[[16]byte](1, objpool.WithScrub[[16]byte]())
+	y := k.Get()
+	y[0] = 0xff
+	k.Put(y)
+	assert(k.Reset() == nil, "reset")
+	assert(y[0] == 0, "reset didn't scrub")
+}
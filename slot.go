@@ -0,0 +1,169 @@
+// slot.go - map objects to their slot in the backing array
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// slot returns the index of 'x' in the backing array or -1 if 'x'
+// doesn't point to an element of the backing array; the caller must
+// hold the lock.
+func (p *Pool[T]) slot(x *T) int {
+	if p.objIdx != nil {
+		if i, ok := p.objIdx[x]; ok {
+			return i
+		}
+		return -1
+	}
+	return slotOf(p.arr, x)
+}
+
+// owns returns true if 'x' points into the backing array. Unlike slot,
+// it doesn't need the lock: it works off the atomically published
+// backing array, which is what lets one pool check objects against
+// another without risking lock order inversions.
+func (p *Pool[T]) owns(x *T) bool {
+	if p.objIdx != nil {
+		_, ok := p.objIdx[x]
+		return ok
+	}
+
+	arr := p.backing.Load()
+	return arr != nil && slotOf(*arr, x) >= 0
+}
+
+// Owns returns true if 'x' could have come from the pool, i.e. if it
+// points to one of the objects of the pool, regardless of whether it's
+// currently free or checked out. It's a pointer range check that takes
+// no lock and keeps no per-slot state, so it's much cheaper than the
+// ownership tracking of SetDebug; callers can use it to route an object
+// back to the pool it came from in tiered or sharded setups. A queue
+// created by NewQueue owns nothing, and neither does a pool of a zero
+// sized type, since its objects can't be told apart.
+func (p *Pool[T]) Owns(x *T) bool {
+	return p.owns(x)
+}
+
+// slotOf returns the index of 'x' in 'arr' or -1 if 'x' doesn't point
+// to an element of 'arr'. Zero sized types share a single address and
+// can't be mapped to a slot; slotOf always returns -1 for them.
+func slotOf[T any](arr []T, x *T) int {
+	if x == nil || len(arr) == 0 {
+		return -1
+	}
+
+	sz := unsafe.Sizeof(arr[0])
+	if sz == 0 {
+		return -1
+	}
+
+	base := uintptr(unsafe.Pointer(&arr[0]))
+	ptr := uintptr(unsafe.Pointer(x))
+	if ptr < base {
+		return -1
+	}
+
+	off := ptr - base
+	if off%sz != 0 {
+		return -1
+	}
+
+	i := off / sz
+	if i >= uintptr(len(arr)) {
+		return -1
+	}
+	return int(i)
+}
+
+// zeroSized returns true if T is a zero sized type
+func (p *Pool[T]) zeroSized() bool {
+	var zero T
+	return unsafe.Sizeof(zero) == 0
+}
+
+// fill enqueues every element of the backing array in order; the
+// caller must hold the lock or have exclusive access to the pool.
+// Compact pools of zero sized types can't map objects back to their
+// index and fall back to a ring of pointers.
+func (p *Pool[T]) fill() {
+	if p.compact && !p.zeroSized() {
+		if p.idx == nil {
+			p.idx = make([]int32, p.size)
+		}
+		for i := range p.idx {
+			p.idx[i] = int32(i)
+		}
+		return
+	}
+
+	if p.q == nil {
+		p.q = make([]*T, p.size)
+	}
+	if p.objs != nil {
+		copy(p.q, p.objs)
+		return
+	}
+	for i := range p.arr {
+		p.q[i] = &p.arr[i]
+	}
+}
+
+// nobjs returns the number of objects owned by the pool
+func (p *Pool[T]) nobjs() int {
+	if p.objs != nil {
+		return len(p.objs)
+	}
+	return len(p.arr)
+}
+
+// obj returns the object in slot 'i'
+func (p *Pool[T]) obj(i int) *T {
+	if p.objs != nil {
+		return p.objs[i]
+	}
+	return &p.arr[i]
+}
+
+// at returns the object at position 'j' of the free queue
+func (p *Pool[T]) at(j int) *T {
+	if p.idx != nil {
+		return &p.arr[p.idx[j]]
+	}
+	return p.q[j]
+}
+
+// setAt stores 'x' at position 'j' of the free queue. Compact pools
+// can only hold objects of the backing array; setAt panics on anything
+// else without modifying the queue.
+func (p *Pool[T]) setAt(j int, x *T) {
+	if p.idx == nil {
+		p.q[j] = x
+		return
+	}
+	p.setIdx(j, x)
+}
+
+// setIdx is setAt for compact pools
+func (p *Pool[T]) setIdx(j int, x *T) {
+	i := p.slot(x)
+	if i < 0 {
+		msg := fmt.Sprintf("%s: %p doesn't belong to the pool", p.label(), x)
+		panic(msg)
+	}
+	p.idx[j] = int32(i)
+}
+
+// freeEach calls 'fn' for every object in the free queue in the order
+// in which they'd be handed out, or least recently returned first for
+// pools created WithLRU; the caller must hold the lock.
+func (p *Pool[T]) freeEach(fn func(x *T)) {
+	for i, j := 0, p.rd; i < p.avail; i++ {
+		fn(p.at(j))
+		j = p.inc(j)
+	}
+}
@@ -0,0 +1,203 @@
+// snapshot.go - consistent structural snapshot of the pool
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PoolSnapshot captures the internal structure of the pool at a point
+// in time. Unlike Stats, it includes the ring indices and the contents
+// of the free queue, which is useful for diagnosing ring-wrap bugs and
+// for attaching to bug reports.
+type PoolSnapshot struct {
+	Cap   int
+	Rd    int
+	Wr    int
+	Avail int
+
+	// Free is the slot index of every object in the free queue in the
+	// order they'd be handed out, or the reverse order for pools
+	// created WithLRU; objects that aren't part of the
+	// backing array are recorded as -1.
+	Free []int
+}
+
+// Snapshot returns a consistent snapshot of the internal state of the
+// pool; it is copied under the pool lock.
+func (p *Pool[T]) Snapshot() PoolSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := PoolSnapshot{
+		Cap:   p.size,
+		Rd:    p.rd,
+		Wr:    p.wr,
+		Avail: p.avail,
+		Free:  make([]int, 0, p.avail),
+	}
+
+	p.freeEach(func(x *T) {
+		s.Free = append(s.Free, p.slot(x))
+	})
+	return s
+}
+
+// String renders the snapshot in a readable form
+func (s PoolSnapshot) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "cap=%d rd=%d wr=%d avail=%d free=[", s.Cap, s.Rd, s.Wr, s.Avail)
+	for i, j := range s.Free {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%d", j)
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// Dump renders the state of the pool in the format of
+// PoolSnapshot.String but lists at most 'limit' entries of the free
+// queue and elides the rest with a count. Unlike Snapshot, the work
+// done under the lock is bounded by 'limit', so it's safe to call on a
+// pool with millions of slots, e.g. when attaching pool state to a bug
+// report.
+func (p *Pool[T]) Dump(limit int) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+
+	n := min(max(limit, 0), p.avail)
+	fmt.Fprintf(&b, "cap=%d rd=%d wr=%d avail=%d free=[", p.size, p.rd, p.wr, p.avail)
+	for i, j := 0, p.rd; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%d", p.slot(p.at(j)))
+		j = p.inc(j)
+	}
+	if n < p.avail {
+		if n > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "... (+%d more)", p.avail-n)
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// NextFree returns the object that the next Get will hand out, without
+// removing it from the free queue; it returns nil if the pool is empty,
+// in which case the next Get returns whatever is Put first. Since the
+// free queue is FIFO, an object that is Put goes behind every other free
+// object (or, for pools created WithLRU, in front of them); NextFree
+// lets tests assert exactly which object a Get returns after a known
+// sequence of Puts. The result is only meaningful if no other goroutine
+// uses the pool concurrently.
+func (p *Pool[T]) NextFree() *T {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.avail == 0:
+		return nil
+	case p.lru:
+		return p.at(p.dec(p.wr))
+	}
+	return p.at(p.rd)
+}
+
+// PoolDiff is the change between two snapshots of a pool; see Diff.
+type PoolDiff struct {
+	// Allocated are the slots that were free in the first snapshot
+	// and checked out in the second; Freed the reverse. Both are in
+	// ascending order.
+	Allocated []int
+	Freed     []int
+
+	// AvailDelta is the change in the number of free objects
+	AvailDelta int
+
+	// RdMoved and WrMoved are how far the ring indices advanced,
+	// modulo the capacity.
+	RdMoved int
+	WrMoved int
+}
+
+// Diff computes what changed in a pool between the snapshots 'before'
+// and 'after', which lets tests assert e.g. that exactly two given
+// slots were handed out between two checkpoints. It only sees the net
+// change: an object that was handed out and returned in between shows
+// up in neither Allocated nor Freed (though it may move the ring
+// indices), and objects that aren't part of the backing array aren't
+// tracked. Both snapshots must be of the same pool.
+func Diff(before, after PoolSnapshot) PoolDiff {
+	was := make(map[int]bool, len(before.Free))
+	for _, i := range before.Free {
+		was[i] = true
+	}
+
+	d := PoolDiff{
+		AvailDelta: after.Avail - before.Avail,
+		RdMoved:    ringDist(before.Rd, after.Rd, after.Cap),
+		WrMoved:    ringDist(before.Wr, after.Wr, after.Cap),
+	}
+
+	for _, i := range after.Free {
+		if i < 0 {
+			continue
+		}
+		if was[i] {
+			delete(was, i)
+		} else {
+			d.Freed = append(d.Freed, i)
+		}
+	}
+	for i := range was {
+		if i >= 0 {
+			d.Allocated = append(d.Allocated, i)
+		}
+	}
+
+	sort.Ints(d.Allocated)
+	sort.Ints(d.Freed)
+	return d
+}
+
+// ringDist returns how far a ring index moved from 'a' to 'b'
+func ringDist(a, b, n int) int {
+	if n == 0 {
+		return 0
+	}
+	return ((b-a)%n + n) % n
+}
+
+// String renders the diff in a readable form
+func (d PoolDiff) String() string {
+	var b strings.Builder
+
+	ints := func(v []int) {
+		b.WriteByte('[')
+		for i, j := range v {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(&b, "%d", j)
+		}
+		b.WriteByte(']')
+	}
+
+	fmt.Fprintf(&b, "avail%+d rd+%d wr+%d allocated=", d.AvailDelta, d.RdMoved, d.WrMoved)
+	ints(d.Allocated)
+	b.WriteString(" freed=")
+	ints(d.Freed)
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestSnapshot(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+	a := o.Get()
+	b := o.Get()
+	o.Put(a)
+
+	s := o.Snapshot()
+	assert(s.Cap == 4 && s.Avail == 3, "snap: %s", s)
+	assert(s.Rd == 2 && s.Wr == 1, "snap: %s", s)
+	assert(len(s.Free) == 3, "free: exp 3, saw %d", len(s.Free))
+	assert(s.Free[0] == 2 && s.Free[1] == 3 && s.Free[2] == 0, "free order: %v", s.Free)
+
+	exp := "cap=4 rd=2 wr=1 avail=3 free=[2 3 0]"
+	assert(s.String() == exp, "string: exp %q, saw %q", exp, s.String())
+
+	o.Put(b)
+}
+
+func TestDump(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+	a := o.Get()
+	b := o.Get()
+	o.Put(a)
+
+	exp := "cap=4 rd=2 wr=1 avail=3 free=[2 3 0]"
+	assert(o.Dump(10) == exp, "dump: exp %q, saw %q", exp, o.Dump(10))
+	assert(o.Dump(3) == exp, "dump: exp %q, saw %q", exp, o.Dump(3))
+
+	exp = "cap=4 rd=2 wr=1 avail=3 free=[2 ... (+2 more)]"
+	assert(o.Dump(1) == exp, "dump: exp %q, saw %q", exp, o.Dump(1))
+
+	exp = "cap=4 rd=2 wr=1 avail=3 free=[... (+3 more)]"
+	assert(o.Dump(0) == exp, "dump: exp %q, saw %q", exp, o.Dump(0))
+	assert(o.Dump(-1) == exp, "dump: exp %q, saw %q", exp, o.Dump(-1))
+
+	o.Put(b)
+}
+
+func TestNextFree(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](2)
+	a := o.NextFree()
+	assert(a != nil && o.Avail() == 2, "nextfree mutated the pool")
+	assert(o.Get() == a, "get didn't return nextfree")
+
+	b := o.Get()
+	assert(o.NextFree() == nil, "nextfree on empty pool")
+
+	o.Put(b)
+	o.Put(a)
+	assert(o.NextFree() == b, "FIFO order broken")
+	assert(o.Get() == b && o.Get() == a, "get order broken")
+}
+
+func TestDiff(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+	a := o.Get()
+	s0 := o.Snapshot()
+
+	o.GetN(2)
+	o.Put(a)
+
+	// handed out and returned in between; not in the diff
+	o.Put(o.Get())
+
+	d := objpool.Diff(s0, o.Snapshot())
+	exp := "avail-1 rd+3 wr+2 allocated=[1 2] freed=[0]"
+	assert(d.String() == exp, "diff:\nexp %s\nsaw %s", exp, d)
+	assert(d.AvailDelta == -1 && len(d.Allocated) == 2, "diff: %+v", d)
+
+	d = objpool.Diff(s0, s0)
+	assert(d.String() == "avail+0 rd+0 wr+0 allocated=[] freed=[]", "empty diff: %s", d)
+}
@@ -0,0 +1,80 @@
+// spill.go - overflow into a spillover pool
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// maxSpill bounds the number of spillover pools an object visits
+const maxSpill = 4
+
+// SetSpillover makes Put and its variants hand an object that finds
+// the free queue full to 'dst' instead of treating it as an overflow;
+// a nil 'dst' restores the default. This lets a small hot pool overflow
+// into a larger cold pool, forming a tiered cache; e.g. objects taken
+// from the cold pool via GetFrom can be Put into the hot pool.
+//
+// 'dst' enqueues the object without running its reset hook (the object
+// was reset by the pool it was Put to). If 'dst' is full as well, the
+// object moves on to the spillover of 'dst', and so on: spillover pools
+// may form chains or even cycles, but an object visits at most four of
+// them. The last pool it reaches handles it as an overflow: the object
+// is dropped, or with the objpool_debug build tag, the Put panics (see
+// WithDropOnOverflow). Only objects that aren't part of a pool spill
+// out of it: one of its own objects finding the pool full is a double
+// free and is handled as an overflow right away.
+//
+// Note that a spillover pool accepts objects that aren't part of its
+// backing array; it must not be created with WithCompact.
+func (p *Pool[T]) SetSpillover(dst *Pool[T]) {
+	p.spill.Store(dst)
+}
+
+// full handles 'x' finding the free queue full after 'hops' spills; it
+// returns the pool to spill 'x' to or nil if 'x' was handled as an
+// overflow. The caller must hold the lock.
+func (p *Pool[T]) full(x *T, hops int) *Pool[T] {
+	if p.dropFallback() {
+		return nil
+	}
+	if s := p.spillTo(x, hops); s != nil {
+		return s
+	}
+	p.overflow(x)
+	return nil
+}
+
+// spillTo returns the pool to spill 'x' to after 'hops' spills, or nil
+// if 'x' can't spill. Objects of the pool itself never spill.
+func (p *Pool[T]) spillTo(x *T, hops int) *Pool[T] {
+	if s := p.spill.Load(); s != nil && hops < maxSpill && !p.owns(x) {
+		return s
+	}
+	return nil
+}
+
+// spillIn enqueues 'x' spilled from another pool, following the chain
+// of spillover pools if need be.
+func (p *Pool[T]) spillIn(x *T) {
+	for s, hops := p, 1; s != nil; hops++ {
+		s = s.spillOne(x, hops)
+	}
+}
+
+// spillOne enqueues 'x', which has been spilled 'hops' times, and
+// returns the next pool to spill it to if this one is full.
+func (p *Pool[T]) spillOne(x *T, hops int) *Pool[T] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == p.size {
+		return p.full(x, hops)
+	}
+
+	// ownership tracking only knows the objects of this pool
+	if p.owned != nil && p.slot(x) >= 0 {
+		p.trackPut(x)
+	}
+	p.put(x, false)
+	return nil
+}
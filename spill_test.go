@@ -0,0 +1,61 @@
+package objpool_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestSpillover(t *testing.T) {
+	assert := newAsserter(t)
+
+	hot := objpool.New[int](1, objpool.WithDropOnOverflow[int]())
+	cold := objpool.New[int](3)
+	hot.SetSpillover(cold)
+
+	// objects of the cold pool overflow the hot pool back into it
+	a := objpool.GetFrom(hot, cold)
+	b := objpool.GetFrom(hot, cold)
+	c := objpool.GetFrom(hot, cold)
+	assert(hot.Avail() == 0 && cold.Avail() == 1, "avail: %d %d", hot.Avail(), cold.Avail())
+
+	hot.Put(a)
+	hot.Put(b)
+	hot.PutN([]*int{c})
+	assert(hot.Avail() == 1 && cold.Avail() == 3, "avail: %d %d", hot.Avail(), cold.Avail())
+
+	// a cycle of full pools is bounded; the object is an overflow of
+	// the last pool it reaches
+	x := objpool.New[int](1, objpool.WithDropOnOverflow[int]())
+	y := objpool.New[int](1, objpool.WithDropOnOverflow[int]())
+	x.SetSpillover(y)
+	y.SetSpillover(x)
+
+	var foreign int
+	x.Put(&foreign)
+	ov := x.Stats().Overflows + y.Stats().Overflows
+	assert(ov == 1, "overflows: exp 1, saw %d", ov)
+	assert(x.Avail() == 1 && y.Avail() == 1, "accounting corrupted")
+
+	// the other Put variants spill as well
+	d := objpool.GetFrom(hot, cold)
+	e := objpool.GetFrom(hot, cold)
+	f := objpool.GetFrom(hot, cold)
+	assert(!hot.PutIf(d, func(*int) bool { return false }), "putif kept")
+	hot.PutPoison(e)
+	assert(hot.PutWait(context.Background(), f) == nil, "putwait")
+	assert(hot.Avail() == 1 && cold.Avail() == 3, "avail: %d %d", hot.Avail(), cold.Avail())
+
+	// a double free of an object of the hot pool is an overflow of
+	// the hot pool; it must not land in the cold pool
+	h := hot.Get()
+	hot.Put(h)
+	hot.Put(h)
+	assert(hot.Stats().Overflows == 1, "double free: exp 1 overflow, saw %d", hot.Stats().Overflows)
+	assert(cold.Avail() == 3, "double free spilled: cold avail %d", cold.Avail())
+
+	hot.SetSpillover(nil)
+	hot.Put(&foreign)
+	assert(hot.Stats().Overflows == 2, "spilled after SetSpillover(nil)")
+}
@@ -0,0 +1,100 @@
+// stats.go - pool statistics
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+)
+
+// Stats is a point-in-time snapshot of the pool. All fields are
+// captured under the pool lock and are consistent with each other.
+// The field names and json tags are stable and suitable for
+// exporting to log aggregators via encoding/json.
+type Stats struct {
+	// Cap is the fixed capacity of the pool
+	Cap int `json:"cap"`
+
+	// Avail is the number of free objects
+	Avail int `json:"avail"`
+
+	// InUse is the number of checked out objects
+	InUse int `json:"in_use"`
+
+	// Gets and Puts are the cumulative number of successful
+	// Get and Put calls since the pool was created.
+	Gets uint64 `json:"gets"`
+	Puts uint64 `json:"puts"`
+
+	// Misses is the cumulative number of Get calls that found the
+	// pool empty.
+	Misses uint64 `json:"misses"`
+
+	// ResetErrors is the cumulative number of objects that failed
+	// the reset hook and were replaced by a fresh object.
+	ResetErrors uint64 `json:"reset_errors"`
+
+	// BadRefreshes is the cumulative number of objects marked bad via
+	// MarkBad that were refreshed on return.
+	BadRefreshes uint64 `json:"bad_refreshes"`
+
+	// PoisonRebuilds is the cumulative number of objects returned via
+	// PutPoison that were rebuilt before being handed out again.
+	PoisonRebuilds uint64 `json:"poison_rebuilds"`
+
+	// Discards is the cumulative number of objects discarded and
+	// replaced by a fresh object, e.g. by PutIf.
+	Discards uint64 `json:"discards"`
+
+	// Overflows is the cumulative number of objects dropped by a pool
+	// created with WithDropOnOverflow because the free queue was full.
+	Overflows uint64 `json:"overflows"`
+
+	// Duplicates is the cumulative number of Puts ignored because of a
+	// duplicate found within the window set via WithDedupWindow.
+	Duplicates uint64 `json:"duplicates"`
+
+	// Fallbacks is the cumulative number of objects that GetOrFallback
+	// obtained from the fallback allocator because the pool was empty.
+	Fallbacks uint64 `json:"fallbacks"`
+
+	// Utilization is InUse as a percentage of Cap
+	Utilization float64 `json:"utilization_pct"`
+}
+
+// Stats returns a consistent snapshot of the pool statistics
+func (p *Pool[T]) Stats() Stats {
+	p.mu.Lock()
+	s := Stats{
+		Cap:    p.size,
+		Avail:  p.avail,
+		InUse:  p.size - p.avail,
+		Gets:   uint64(p.gets.Load()),
+		Puts:   uint64(p.puts.Load()),
+		Misses: p.misses,
+
+		ResetErrors:  p.resetErrs,
+		BadRefreshes: p.badRefreshes,
+
+		PoisonRebuilds: p.poisonRebuilds,
+		Discards:       p.discards,
+		Overflows:      p.overflows,
+		Duplicates:     p.dups,
+		Fallbacks:      p.fallbacks.Load(),
+	}
+	p.mu.Unlock()
+
+	if s.Cap > 0 {
+		s.Utilization = 100.0 * float64(s.InUse) / float64(s.Cap)
+	}
+	return s
+}
+
+// String returns a string description of the stats
+func (s Stats) String() string {
+	return fmt.Sprintf("cap=%d, avail=%d, in-use=%d (%.1f%%), gets=%d, puts=%d, misses=%d, reset-errs=%d, bad=%d, poisoned=%d, discards=%d, overflows=%d, dups=%d, fallbacks=%d",
+		s.Cap, s.Avail, s.InUse, s.Utilization, s.Gets, s.Puts, s.Misses, s.ResetErrors,
+		s.BadRefreshes, s.PoisonRebuilds, s.Discards, s.Overflows, s.Duplicates, s.Fallbacks)
+}
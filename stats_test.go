@@ -0,0 +1,192 @@
+package objpool_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestStats(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+
+	a := o.Get()
+	b := o.Get()
+	o.Put(a)
+	_ = b
+
+	s := o.Stats()
+	assert(s.Cap == 4, "cap: exp 4, saw %d", s.Cap)
+	assert(s.Avail == 3, "avail: exp 3, saw %d", s.Avail)
+	assert(s.InUse == 1, "in-use: exp 1, saw %d", s.InUse)
+	assert(s.Gets == 2, "gets: exp 2, saw %d", s.Gets)
+	assert(s.Puts == 1, "puts: exp 1, saw %d", s.Puts)
+	assert(s.Utilization == 25.0, "util: exp 25, saw %f", s.Utilization)
+
+	for o.Get() != nil {
+	}
+	s = o.Stats()
+	assert(s.Misses == 1, "misses: exp 1, saw %d", s.Misses)
+
+	buf, err := json.Marshal(s)
+	assert(err == nil, "json: %s", err)
+
+	var m map[string]any
+	err = json.Unmarshal(buf, &m)
+	assert(err == nil, "json unmarshal: %s", err)
+	assert(m["cap"] == 4.0, "json cap: %v", m["cap"])
+	assert(m["utilization_pct"] == 100.0, "json util: %v", m["utilization_pct"])
+}
+
+func TestTotalGetsPuts(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](8)
+
+	a := o.Get()
+	v := o.GetBatch(context.Background(), 4, 0)
+	assert(o.TotalGets() == 5, "gets: exp 5, saw %d", o.TotalGets())
+
+	o.Put(a)
+	for _, x := range v {
+		o.Put(x)
+	}
+	assert(o.TotalPuts() == 5, "puts: exp 5, saw %d", o.TotalPuts())
+
+	// misses don't count
+	for o.Get() != nil {
+	}
+	assert(o.TotalGets() == 13, "gets: exp 13, saw %d", o.TotalGets())
+}
+
+func TestTryAvail(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+	o.Get()
+	n, ok := o.TryAvail()
+	assert(ok && n == 3, "tryavail: exp 3, saw %d %v", n, ok)
+
+	// hold the lock via a callback that runs under it
+	o.ForEach(func(*int) {
+		n, ok = o.TryAvail()
+	})
+	assert(!ok && n == 0, "tryavail on a locked pool: %d %v", n, ok)
+}
+
+func TestBalance(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+	assert(o.Balance() == 0, "balance: exp 0, saw %d", o.Balance())
+
+	a := o.Get()
+	b := o.Get()
+	assert(o.Balance() == 2, "balance: exp 2, saw %d", o.Balance())
+
+	o.Put(a)
+	assert(o.Balance() == o.InUse(), "balance %d != in-use %d", o.Balance(), o.InUse())
+
+	o.Put(b)
+	assert(o.Balance() == 0, "balance: exp 0, saw %d", o.Balance())
+
+	// the Put that would make the balance negative is refused
+	func() {
+		defer func() { recover() }()
+		o.Put(a)
+	}()
+	assert(o.Balance() == 0, "balance: exp 0, saw %d", o.Balance())
+}
+
+func TestEstimatedBytes(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[uint64](100)
+	n := o.EstimatedBytes()
+	assert(n == 100*8+100*8, "estimate: exp 1600, saw %d", n)
+
+	c := objpool.New[uint64](100, objpool.WithCompact[uint64]())
+	n = c.EstimatedBytes()
+	assert(n == 100*8+100*4, "compact estimate: exp 1200, saw %d", n)
+
+	sizer := func(b *[]byte) int64 {
+		return int64(cap(*b))
+	}
+	init := func(b *[]byte) {
+		*b = make([]byte, 0, 1024)
+	}
+	bp := objpool.NewWithInit[[]byte](10, init, objpool.WithSizer(sizer))
+	n = bp.EstimatedBytes()
+	assert(n >= 10*1024, "sizer ignored: %d", n)
+}
+
+func TestUntouchedCount(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](4)
+	assert(o.UntouchedCount() == 4, "untouched: exp 4, saw %d", o.UntouchedCount())
+
+	// the same two slots are reused across Resets
+	for i := 0; i < 3; i++ {
+		a := o.Get()
+		b := o.Get()
+		o.Put(a)
+		o.Put(b)
+		assert(o.Reset() == nil, "reset")
+	}
+	assert(o.UntouchedCount() == 2, "untouched: exp 2, saw %d", o.UntouchedCount())
+
+	v := o.GetN(4)
+	assert(o.UntouchedCount() == 0, "untouched: exp 0, saw %d", o.UntouchedCount())
+	o.PutN(v)
+
+	_, err := o.SwapBacking(make([]int, 4))
+	assert(err == nil, "swap: %v", err)
+	assert(o.UntouchedCount() == 4, "untouched after swap: exp 4, saw %d", o.UntouchedCount())
+
+	q := objpool.NewQueue[int](2)
+	var x int
+	q.Enqueue(&x)
+	q.Dequeue()
+	assert(q.UntouchedCount() == 0, "queue untouched: %d", q.UntouchedCount())
+}
+
+func TestLockMemory(t *testing.T) {
+	assert := newAsserter(t)
+
+	q := objpool.NewQueue[uint64](4)
+	assert(q.LockMemory() != nil, "locked a queue without backing array")
+
+	o := objpool.New[uint64](1024)
+	if err := o.LockMemory(); err != nil {
+		t.Skipf("mlock unavailable: %v", err)
+	}
+	err := o.UnlockMemory()
+	assert(err == nil, "unlock: %v", err)
+}
+
+func TestPublishExpvar(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](3)
+	name := fmt.Sprintf("objpool_test_%p", o)
+	o.PublishExpvar(name)
+	o.Get()
+
+	v := expvar.Get(name)
+	assert(v != nil, "var not published")
+
+	var s objpool.Stats
+	err := json.Unmarshal([]byte(v.String()), &s)
+	assert(err == nil, "json: %v", err)
+	assert(s.Cap == 3 && s.Avail == 2 && s.InUse == 1 && s.Gets == 1, "stats: %+v", s)
+
+	mustPanic(t, func() {
+		o.PublishExpvar(name)
+	})
+}
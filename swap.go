@@ -0,0 +1,142 @@
+// swap.go - replace the backing array of an idle pool
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"fmt"
+)
+
+// SwapBacking replaces the backing array of the pool with 'newArr' and
+// rebuilds the free queue from it; it returns the old backing array so
+// the caller can clean up its objects. This refreshes every pooled
+// object (e.g. connections reopened with a new config) without
+// recreating the pool and whatever refers to it.
+//
+// The swap only succeeds when no objects are checked out - outstanding
+// pointers into the old array would otherwise dangle - and returns
+// ErrInUse otherwise. 'newArr' must have the same length as the pool's
+// capacity; the pool takes ownership of it.
+func (p *Pool[T]) SwapBacking(newArr []T) ([]T, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.swappable(); err != nil {
+		return nil, err
+	}
+	if len(newArr) != p.size {
+		return nil, fmt.Errorf("objpool: backing array of %d objects; exp %d", len(newArr), p.size)
+	}
+
+	p.debugf("swapped backing array of %d objects", p.size)
+	return p.swap(newArr), nil
+}
+
+// TakeAllAndReset hands every free object of the pool to the caller and
+// replaces it with a fresh one, so that the free objects are usable
+// again while the caller processes the old ones; this suits double
+// buffering. The returned objects no longer belong to the pool and must
+// not be Put back. The fresh objects are zero values, or initialized by
+// the init func of NewWithInit or NewIface.
+//
+// Checked out objects stay out and are Put back as usual; only the
+// slots of the free objects are reset. An idle pool gets a fresh
+// backing array and the caller the old one. Otherwise the checked out
+// objects still point into the backing array, so the free objects are
+// moved to a new array instead: the caller gets their values, but not
+// their addresses. Re-enqueueing the backing array as it is would hand
+// the objects returned here out a second time.
+func (p *Pool[T]) TakeAllAndReset() ([]*T, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch err := p.swappable(); {
+	case err == ErrInUse:
+		p.debugf("took %d free objects and reset them", p.avail)
+		return p.takeFree(), nil
+	case err != nil:
+		return nil, err
+	}
+
+	v := make([]*T, 0, p.avail)
+	p.freeEach(func(x *T) {
+		v = append(v, x)
+	})
+
+	p.debugf("took %d objects and reset", len(v))
+	p.swap(make([]T, p.size))
+	if p.init != nil {
+		p.initAll(p.init)
+	}
+	return v, nil
+}
+
+// takeFree moves the free objects to a new array, whose objects it
+// returns, and rebuilds them in place; the caller must hold the lock.
+func (p *Pool[T]) takeFree() []*T {
+	old := make([]T, p.avail)
+	v := make([]*T, 0, p.avail)
+	p.freeEach(func(x *T) {
+		i := len(v)
+		old[i] = *x
+		v = append(v, &old[i])
+
+		var zero T
+		*x = zero
+		if p.init != nil {
+			p.init(x)
+		}
+
+		// the marks of the slot were for the old object
+		if j := p.slot(x); j >= 0 {
+			if p.poison != nil {
+				p.poison.clr(j)
+			}
+			if p.idle != nil {
+				p.idle[j] = now()
+			}
+		}
+	})
+	return v
+}
+
+// swappable returns an error if the backing array of the pool can't be
+// replaced; the caller must hold the lock.
+func (p *Pool[T]) swappable() error {
+	switch {
+	case p.queue || p.objs != nil:
+		return fmt.Errorf("objpool: pool has no backing array")
+	case p.frozen.Load():
+		return ErrFrozen
+	case p.avail != p.size:
+		return ErrInUse
+	}
+	return nil
+}
+
+// swap replaces the backing array with 'newArr' and rebuilds the free
+// queue; it returns the old backing array. The caller must hold the
+// lock and ensure the pool is idle.
+func (p *Pool[T]) swap(newArr []T) []T {
+	old := p.arr
+	p.arr = newArr
+	p.rd = 0
+	p.wr = 0
+	p.fill()
+	p.backing.Store(&p.arr)
+
+	// marks for slots of the old array don't apply to the new one
+	p.poison = nil
+	p.touched = nil
+	p.ntouched = 0
+	if p.idle != nil {
+		p.idle = newIdle(p.size)
+	}
+	if p.checkout != nil {
+		clear(p.checkout)
+	}
+	p.replain()
+	return old
+}
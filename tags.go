@@ -0,0 +1,109 @@
+// tags.go - tag checked out objects for debugging handoffs
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// GetTagged returns a single object from the pool along with its slot
+// index and records 'tag' against the slot until the object is Put
+// back. DumpTags reports the tags of all checked out objects; this
+// helps trace which subsystem holds an object when diagnosing leaks or
+// contention. It returns nil and -1 if the pool has exhausted its
+// capacity. The tag storage is allocated on first use.
+func (p *Pool[T]) GetTagged(tag string) (*T, int) {
+	if p.frozen.Load() {
+		return p.frozenNext(), -1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == 0 {
+		p.miss()
+		return nil, -1
+	}
+
+	x := p.get()
+	i := p.slot(x)
+	if i < 0 {
+		return x, -1
+	}
+
+	if p.tags == nil {
+		p.tags = make(map[int]string)
+		p.replain()
+	}
+	p.tags[i] = tag
+	return x, i
+}
+
+// DumpTags returns the tag of every checked out object that was
+// obtained via GetTagged, keyed by slot index.
+func (p *Pool[T]) DumpTags() map[int]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m := make(map[int]string, len(p.tags))
+	for k, v := range p.tags {
+		m[k] = v
+	}
+	return m
+}
+
+// untag clears the tag of 'x'; the caller must hold the lock.
+func (p *Pool[T]) untag(x *T) {
+	if i := p.slot(x); i >= 0 {
+		delete(p.tags, i)
+	}
+}
+
+// ReclaimTag returns every checked out object tagged 'tag' via
+// GetTagged to the pool, running the reset hook on each as Put would,
+// and returns the number of objects reclaimed. It's a safety net for
+// request scoped pooling: tag the objects of a request with its id and
+// reclaim them when the request finishes, even if the handler forgot
+// some Puts.
+//
+// The reclaimed objects can be handed out again right away; so the
+// holders must not use them, or Put them, after ReclaimTag - such a Put
+// is a double free. The reset hook runs with the pool lock held and
+// must not call back into the pool.
+func (p *Pool[T]) ReclaimTag(tag string) int {
+	if p.frozen.Load() {
+		return 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var v []int
+	for i, t := range p.tags {
+		if t == tag {
+			v = append(v, i)
+		}
+	}
+
+	for _, i := range v {
+		p.reclaim(p.obj(i))
+	}
+	return len(v)
+}
+
+// reclaim returns the checked out object 'x' to the pool on behalf of
+// its holder, running the reset hook as Put would; the caller must hold
+// the lock.
+func (p *Pool[T]) reclaim(x *T) {
+	if p.owned != nil {
+		p.trackPut(x)
+	}
+
+	var bad bool
+	if p.reset != nil {
+		if err := p.reset(x, 0); err != nil {
+			p.renew(x)
+			bad = true
+		}
+	}
+	p.put(x, bad)
+}
@@ -0,0 +1,63 @@
+package objpool_test
+
+import (
+	"testing"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestTags(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](3)
+
+	a, ia := o.GetTagged("http")
+	b, ib := o.GetTagged("db")
+	c := o.Get()
+	assert(a != nil && b != nil && c != nil, "expected objs")
+	assert(ia != ib && ia >= 0 && ib >= 0, "bad slots %d, %d", ia, ib)
+
+	m := o.DumpTags()
+	assert(len(m) == 2, "tags: exp 2, saw %d", len(m))
+	assert(m[ia] == "http" && m[ib] == "db", "wrong tags: %v", m)
+
+	o.Put(a)
+	m = o.DumpTags()
+	assert(len(m) == 1 && m[ib] == "db", "tag not cleared on put: %v", m)
+
+	a, _ = o.GetTagged("again")
+	x, i := o.GetTagged("none")
+	assert(x == nil && i == -1, "exp nil from exhausted pool")
+}
+
+func TestReclaimTag(t *testing.T) {
+	assert := newAsserter(t)
+
+	var resets int
+	o := objpool.New[int](4, objpool.WithReset(func(x *int) error {
+		resets++
+		*x = 0
+		return nil
+	}))
+	o.SetDebug(true)
+
+	a, _ := o.GetTagged("req-1")
+	b, _ := o.GetTagged("req-2")
+	c, _ := o.GetTagged("req-1")
+	*a, *c = 1, 1
+
+	assert(o.ReclaimTag("req-3") == 0, "reclaimed unknown tag")
+	n := o.ReclaimTag("req-1")
+	assert(n == 2 && o.Avail() == 3, "reclaimed %d, avail %d", n, o.Avail())
+	assert(*a == 0 && *c == 0 && resets == 2, "reset hook not run")
+
+	tags := o.DumpTags()
+	assert(len(tags) == 1, "tags left: %v", tags)
+
+	// reclaimed objects are free; a late Put by the holder is a double free
+	mustPanic(t, func() {
+		o.Put(a)
+	})
+	o.Put(b)
+	assert(o.Avail() == 4, "avail: exp 4, saw %d", o.Avail())
+}
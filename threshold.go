@@ -0,0 +1,47 @@
+// threshold.go - callbacks for low availability
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// threshold is a level registered via OnThreshold; 'below' is true
+// while the pool is at or below the level.
+type threshold struct {
+	level int
+	fn    func()
+	below bool
+}
+
+// OnThreshold registers 'fn' to be called, in its own goroutine,
+// whenever the number of free objects drops to or below 'level'. The
+// callback is edge triggered: it fires once per crossing and not again
+// while the pool stays at or below the level; it re-arms once the pool
+// rises above the level. So a pool hovering around the threshold fires
+// once for every dip, not for every Get. If the pool is already at or
+// below 'level', 'fn' fires right away. Multiple thresholds can be
+// registered, e.g. one at 10% of Cap to start scaling and one at zero
+// to alert; they can't be removed.
+func (p *Pool[T]) OnThreshold(level int, fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lows = append(p.lows, threshold{level: level, fn: fn})
+	p.replain()
+	p.crossings()
+}
+
+// crossings fires the thresholds that the pool dropped to and re-arms
+// those it rose above; the caller must hold the lock.
+func (p *Pool[T]) crossings() {
+	for i := range p.lows {
+		t := &p.lows[i]
+		switch below := p.avail <= t.level; {
+		case below && !t.below:
+			t.below = true
+			go t.fn()
+		case !below:
+			t.below = false
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package objpool_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestOnThreshold(t *testing.T) {
+	assert := newAsserter(t)
+
+	var low, empty atomic.Int32
+	fired := make(chan struct{}, 16)
+
+	o := objpool.New[int](10)
+	o.OnThreshold(2, func() {
+		low.Add(1)
+		fired <- struct{}{}
+	})
+	o.OnThreshold(0, func() {
+		empty.Add(1)
+		fired <- struct{}{}
+	})
+
+	wait := func(n int) {
+		for i := 0; i < n; i++ {
+			select {
+			case <-fired:
+			case <-time.After(time.Second):
+				t.Fatalf("threshold didn't fire")
+			}
+		}
+	}
+
+	v := o.GetN(8)
+	wait(1)
+	assert(low.Load() == 1, "low: exp 1, saw %d", low.Load())
+
+	// hovering below the level doesn't fire again
+	x := o.Get()
+	o.Put(x)
+	x = o.Get()
+	assert(low.Load() == 1, "low fired while below: %d", low.Load())
+
+	// rising above re-arms
+	o.Put(x)
+	o.Put(v[0])
+	v[0] = o.Get()
+	wait(1)
+	assert(low.Load() == 2, "low: exp 2, saw %d", low.Load())
+
+	o.GetN(2)
+	wait(1)
+	assert(empty.Load() == 1, "empty: exp 1, saw %d", empty.Load())
+
+	// registering below the level fires right away
+	o.OnThreshold(5, func() {
+		fired <- struct{}{}
+	})
+	wait(1)
+}
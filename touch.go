@@ -0,0 +1,48 @@
+// touch.go - track slots that were ever handed out
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// UntouchedCount returns the number of slots whose object was never
+// handed out by Get over the lifetime of the pool (or since the last
+// SwapBacking). It's a capacity planning signal: a pool that keeps a
+// large number of untouched slots is oversized.
+//
+// Note that the free queue is FIFO: an object that is returned goes to
+// the back of the queue, so Get hands out every slot before reusing any.
+// The untouched count therefore drops to zero as soon as the pool has
+// served Cap gets between Resets and is most telling for pools that
+// are rarely Reset. Queues and pools of zero sized objects have no
+// slots to track and always report zero.
+func (p *Pool[T]) UntouchedCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.size - p.ntouched
+}
+
+// touch marks the slot of 'x' as handed out; the caller must hold the
+// lock. Get only calls it while some slot is untouched, so it costs
+// nothing once the pool is warm.
+func (p *Pool[T]) touch(x *T) {
+	i := p.slot(x)
+	if i < 0 {
+		// no slots to track
+		p.ntouched = p.size
+		return
+	}
+
+	if p.touched == nil {
+		p.touched = newBitset(p.nobjs())
+	}
+	if !p.touched.isset(i) {
+		p.touched.set(i)
+		p.ntouched += 1
+	}
+
+	if p.ntouched == p.size {
+		p.touched = nil
+	}
+}
@@ -0,0 +1,375 @@
+// wait.go - blocking variants of Get
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// GetTimeout returns a single object from the pool, waiting for at
+// most 'd' for one to be returned if the pool is exhausted. It returns
+// nil if no object became available in that time. A non-positive 'd'
+// is equivalent to Get.
+func (p *Pool[T]) GetTimeout(d time.Duration) *T {
+	if d <= 0 {
+		return p.Get()
+	}
+
+	if x := p.tryGet(); x != nil {
+		return x
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	x, _ := p.getWait(ctx)
+	return x
+}
+
+// GetDeadline returns a single object from the pool, waiting until the
+// absolute time 't' for one to be returned if the pool is exhausted. It
+// returns nil if no object became available by the deadline. A deadline
+// that has already passed results in a single non-blocking attempt.
+//
+// Waiters sleep on a condition variable that is signalled once for every
+// object returned via Put; a waiter that is woken always re-checks the
+// pool under the lock, so an object that is grabbed by a concurrent Get
+// before the waiter runs simply puts the waiter back to sleep. When the
+// deadline fires, all waiters are woken so the expired ones can return.
+func (p *Pool[T]) GetDeadline(t time.Time) *T {
+	if !now().Before(t) {
+		return p.Get()
+	}
+
+	if x := p.tryGet(); x != nil {
+		return x
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), t)
+	defer cancel()
+
+	x, _ := p.getWait(ctx)
+	return x
+}
+
+// GetContext returns a single object from the pool, blocking until one
+// is available or the context is done. It returns the context's error
+// in the latter case, and right away if the context is already done,
+// even if an object is free.
+func (p *Pool[T]) GetContext(ctx context.Context) (*T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if x := p.tryGet(); x != nil {
+		return x, nil
+	}
+	return p.getWait(ctx)
+}
+
+// GetContextTimed is like GetContext but also returns how long the
+// caller was blocked waiting for an object; the duration is zero if an
+// object was free right away. This lets request handlers attribute
+// latency to pool waits without timing every call themselves. On error
+// the duration is the time spent waiting before the context was done.
+func (p *Pool[T]) GetContextTimed(ctx context.Context) (*T, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	if x := p.tryGet(); x != nil {
+		return x, 0, nil
+	}
+
+	t0 := now()
+	x, err := p.getWait(ctx)
+	return x, now().Sub(t0), err
+}
+
+// GetJittered is like GetContext but desynchronizes waiters: when the
+// pool is exhausted it waits for an object to be returned and then
+// sleeps for a random delay of up to 'maxJitter' before trying to take
+// it. When many goroutines are blocked and a batch of objects is freed,
+// this spreads their retries out instead of making them contend for the
+// lock in lockstep. The price is added latency of up to 'maxJitter' per
+// retry; a waiter may also lose the race for the object to a Get that
+// arrives during its delay, in which case it waits again.
+func (p *Pool[T]) GetJittered(ctx context.Context, maxJitter time.Duration) (*T, error) {
+	if maxJitter <= 0 {
+		return p.GetContext(ctx)
+	}
+
+	if p.slowGet > 0 {
+		t0 := now()
+		defer func() {
+			p.mu.Lock()
+			p.slowCheck(t0)
+			p.mu.Unlock()
+		}()
+	}
+	for {
+		if x := p.tryGet(); x != nil {
+			return x, nil
+		}
+
+		err := p.WaitAvail(ctx, 1)
+		if err == nil {
+			err = sleepCtx(ctx, time.Duration(rand.Int63n(int64(maxJitter))))
+		}
+		if err != nil {
+			p.mu.Lock()
+			p.miss()
+			p.mu.Unlock()
+			return nil, err
+		}
+	}
+}
+
+// sleepCtx sleeps for 'd' or until the context is done
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetBatch returns up to 'maxN' objects from the pool. It blocks until
+// at least one object is available or the context is done; once it has
+// the first object it collects whatever else is free, and if it still
+// has fewer than 'maxN' it waits at most 'linger' for more objects to be
+// returned before handing back the batch. The linger window thus bounds
+// the latency added for the sake of a larger batch.
+//
+// GetBatch returns an empty, non-nil slice if the context is done
+// before the first object is obtained. If the context is done while
+// lingering, the objects collected so far are returned; they are never
+// dropped.
+func (p *Pool[T]) GetBatch(ctx context.Context, maxN int, linger time.Duration) []*T {
+	if maxN <= 0 {
+		return []*T{}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// the linger is by design and doesn't count as being slow
+	var t0 time.Time
+	if p.slowGet > 0 {
+		t0 = now()
+	}
+	err := p.waitFor(ctx, p.nonEmpty)
+	if p.slowGet > 0 {
+		p.slowCheck(t0)
+	}
+	if err != nil {
+		p.miss()
+		return []*T{}
+	}
+
+	v := make([]*T, 0, maxN)
+	v = p.take(v, maxN)
+	if len(v) == maxN || linger <= 0 {
+		return v
+	}
+
+	lctx, cancel := context.WithTimeout(ctx, linger)
+	defer cancel()
+
+	for len(v) < maxN {
+		if err := p.waitFor(lctx, p.nonEmpty); err != nil {
+			break
+		}
+		v = p.take(v, maxN)
+	}
+	return v
+}
+
+// take appends free objects to 'v' until it has 'maxN' elements or the
+// pool is empty. The limit of WithMaxOutstanding caps the batch and
+// fires the alert if the batch reaches it. The caller must hold the
+// lock.
+func (p *Pool[T]) take(v []*T, maxN int) []*T {
+	for len(v) < maxN && p.avail > 0 {
+		if p.maxOut > 0 && p.refuse() {
+			break
+		}
+		v = append(v, p.get())
+	}
+	return v
+}
+
+// WaitAvail blocks until at least 'k' objects are free or the context
+// is done; unlike GetBatch it doesn't consume the objects, it only waits
+// for headroom. A 'k' larger than the capacity of the pool can never be
+// satisfied and returns an error right away. Spurious wakeups of the
+// underlying condition variable are handled internally by re-checking
+// availability. Note that by the time WaitAvail returns, concurrent
+// callers may already have consumed some of the free objects.
+func (p *Pool[T]) WaitAvail(ctx context.Context, k int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k > p.size {
+		return fmt.Errorf("objpool: wait for %d exceeds capacity %d", k, p.size)
+	}
+
+	// a Put only signals one waiter; if that is us and we don't have
+	// enough headroom yet, the signal would be lost for a Get waiter.
+	// So Put broadcasts while there are WaitAvail callers.
+	p.availWaiters += 1
+	defer func() {
+		p.availWaiters -= 1
+	}()
+
+	return p.waitFor(ctx, func() bool {
+		return p.avail >= k
+	})
+}
+
+// tryGet returns a free object or nil without counting a miss; it
+// lets the blocking variants skip setting up a timer when the pool
+// isn't empty.
+func (p *Pool[T]) tryGet() *T {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.nonEmpty() {
+		return nil
+	}
+	return p.get()
+}
+
+// getWait blocks until an object is available or the context is done.
+// An object that is available when the waiter wakes up is always taken,
+// even if the context expired concurrently; this guarantees that a
+// Signal from Put is never lost on an expiring waiter.
+func (p *Pool[T]) getWait(ctx context.Context) (*T, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.slowGet > 0 {
+		defer p.slowCheck(now())
+	}
+	if err := p.waitFor(ctx, p.nonEmpty); err != nil {
+		p.miss()
+		return nil, err
+	}
+	return p.get(), nil
+}
+
+// waitFor blocks on the condition variable until ok() is true or the
+// context is done; the caller must hold the lock. ok() is always checked
+// before the context, so a waiter that is woken by a Signal consumes it.
+func (p *Pool[T]) waitFor(ctx context.Context, ok func() bool) error {
+	return p.waitOn(ctx, p.cond, &p.waiters, ok)
+}
+
+// waitOn is waitFor on the condition variable 'c' whose number of
+// blocked goroutines is tracked in 'n'.
+func (p *Pool[T]) waitOn(ctx context.Context, c *sync.Cond, n *int, ok func() bool) error {
+	if ok() {
+		return nil
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		p.mu.Lock()
+		c.Broadcast()
+		p.mu.Unlock()
+	})
+	defer stop()
+
+	for !ok() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		*n += 1
+		c.Wait()
+		*n -= 1
+	}
+	return nil
+}
+
+// PutWait returns the object back to the pool; if the free queue is
+// full, it waits for room or for the context to be done instead of
+// failing like Put. The queue is never full in correct usage of a
+// fixed pool; PutWait is for callers that use the pool as a bounded
+// buffer and would rather wait briefly than fail. The reset hook is
+// run before waiting. PutWait returns the context's error if it gave up;
+// in that case 'x' was not returned to the pool. An object that can go
+// to the spillover pool (see SetSpillover) does so instead of waiting.
+func (p *Pool[T]) PutWait(ctx context.Context, x *T) error {
+	if p.frozen.Load() || p.fallback(x) {
+		return nil
+	}
+
+	bad := p.runReset(x, 0)
+	s, err := p.putWait(ctx, x, bad)
+	if s != nil {
+		s.spillIn(x)
+	}
+	return err
+}
+
+// putWait is the locked part of PutWait; it returns the spillover pool
+// if 'x' must go there instead of waiting for room.
+func (p *Pool[T]) putWait(ctx context.Context, x *T, bad bool) (*Pool[T], error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.dedup > 0 && p.isDup(x) {
+		return nil, nil
+	}
+	if p.avail == p.size {
+		if p.dropFallback() {
+			return nil, nil
+		}
+		if s := p.spillTo(x, 0); s != nil {
+			return s, nil
+		}
+	}
+
+	err := p.waitOn(ctx, p.room, &p.roomWaiters, func() bool {
+		return p.avail < p.size
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if p.owned != nil {
+		p.trackPut(x)
+	}
+	p.put(x, bad)
+	return nil, nil
+}
+
+// nonEmpty returns true if the pool has free objects; the caller must
+// hold the lock.
+func (p *Pool[T]) nonEmpty() bool {
+	return p.avail > 0 && !(p.maxOut > 0 && p.refuse())
+}
+
+// slowCheck fires the callback of WithSlowGetThreshold if a blocking
+// Get that started waiting at 't0' was slow; the caller must hold the
+// lock.
+func (p *Pool[T]) slowCheck(t0 time.Time) {
+	t := now()
+	waited := t.Sub(t0)
+	if waited <= p.slowGet || t.Sub(p.lastSlow) < p.slowGet {
+		return
+	}
+
+	p.lastSlow = t
+	if p.onSlow != nil {
+		go p.onSlow(waited)
+	}
+}
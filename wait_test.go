@@ -0,0 +1,337 @@
+package objpool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestGetTimeout(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](1)
+	a := o.Get()
+	assert(a != nil, "expected obj; got nil")
+
+	start := time.Now()
+	b := o.GetTimeout(20 * time.Millisecond)
+	assert(b == nil, "expected nil on timeout")
+	assert(time.Since(start) >= 20*time.Millisecond, "returned too early")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		o.Put(a)
+	}()
+
+	b = o.GetTimeout(5 * time.Second)
+	assert(b == a, "expected returned obj %p, saw %p", a, b)
+}
+
+func TestGetDeadline(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](1)
+
+	// deadline in the past is a non-blocking get
+	a := o.GetDeadline(time.Now().Add(-time.Second))
+	assert(a != nil, "expected obj; got nil")
+
+	b := o.GetDeadline(time.Now().Add(-time.Second))
+	assert(b == nil, "expected nil from empty pool")
+
+	b = o.GetDeadline(time.Now().Add(20 * time.Millisecond))
+	assert(b == nil, "expected nil on deadline")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		o.Put(a)
+	}()
+
+	b = o.GetDeadline(time.Now().Add(5 * time.Second))
+	assert(b == a, "expected returned obj %p, saw %p", a, b)
+}
+
+func TestGetBatch(t *testing.T) {
+	assert := newAsserter(t)
+
+	size := 4
+	o := objpool.New[int](size)
+
+	v := o.GetBatch(context.Background(), 3, 0)
+	assert(len(v) == 3, "batch: exp 3, saw %d", len(v))
+
+	// only one left; no linger means we return what's ready
+	w := o.GetBatch(context.Background(), 3, 0)
+	assert(len(w) == 1, "batch: exp 1, saw %d", len(w))
+
+	// empty pool and a cancelled context returns an empty batch
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	x := o.GetBatch(ctx, 2, time.Second)
+	cancel()
+	assert(x != nil && len(x) == 0, "exp empty batch, saw %d", len(x))
+
+	// objects returned during linger are collected
+	go func() {
+		o.Put(v[0])
+		time.Sleep(10 * time.Millisecond)
+		o.Put(v[1])
+	}()
+
+	y := o.GetBatch(context.Background(), 2, 5*time.Second)
+	assert(len(y) == 2, "linger batch: exp 2, saw %d", len(y))
+
+	// linger expiring returns the partial batch
+	o.Put(y[0])
+	start := time.Now()
+	z := o.GetBatch(context.Background(), 2, 20*time.Millisecond)
+	assert(len(z) == 1, "partial batch: exp 1, saw %d", len(z))
+	assert(time.Since(start) >= 20*time.Millisecond, "returned before linger")
+}
+
+func TestGetContext(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](1)
+	a, err := o.GetContext(context.Background())
+	assert(err == nil && a != nil, "get: %v", err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	b, err := o.GetContext(ctx)
+	assert(b == nil, "exp nil on cancel")
+	assert(err == context.Canceled, "exp context.Canceled, saw %v", err)
+
+	// a done context fails even if an object is free
+	o.Put(a)
+	b, err = o.GetContext(ctx)
+	assert(b == nil && err == context.Canceled, "free object: %v", err)
+	_, d, err := o.GetContextTimed(ctx)
+	assert(d == 0 && err == context.Canceled, "timed: %v", err)
+	assert(o.Avail() == 1, "avail: %d", o.Avail())
+}
+
+func TestGetContextTimed(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](1)
+	a, d, err := o.GetContextTimed(context.Background())
+	assert(err == nil && a != nil && d == 0, "fast path: %v %s", err, d)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		o.Put(a)
+	}()
+
+	b, d, err := o.GetContextTimed(context.Background())
+	assert(err == nil && b == a, "get: %v", err)
+	assert(d >= 20*time.Millisecond, "wait: %s", d)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	b, d, err = o.GetContextTimed(ctx)
+	assert(b == nil && err == context.DeadlineExceeded, "timeout: %v", err)
+	assert(d >= 10*time.Millisecond, "wait: %s", d)
+}
+
+func TestWaitAvail(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](3)
+	v := o.GetBatch(context.Background(), 3, 0)
+
+	err := o.WaitAvail(context.Background(), 4)
+	assert(err != nil, "exp error for k > cap")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	err = o.WaitAvail(ctx, 1)
+	cancel()
+	assert(err == context.DeadlineExceeded, "exp deadline exceeded, saw %v", err)
+
+	// a Get waiter must not starve behind a WaitAvail waiter
+	done := make(chan *int)
+	go func() {
+		x, _ := o.GetContext(context.Background())
+		done <- x
+	}()
+
+	werr := make(chan error)
+	go func() {
+		werr <- o.WaitAvail(context.Background(), 2)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	o.Put(v[0])
+	x := <-done
+	assert(x == v[0], "get waiter not served")
+
+	o.Put(v[1])
+	o.Put(v[2])
+	err = <-werr
+	assert(err == nil, "wait avail: %v", err)
+	assert(o.Avail() == 2, "wait avail consumed objects: %d", o.Avail())
+}
+
+func TestPutWait(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](1)
+
+	// the queue is full; so PutWait must wait for room
+	var extra int
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	err := o.PutWait(ctx, &extra)
+	cancel()
+	assert(err == context.DeadlineExceeded, "exp deadline exceeded, saw %v", err)
+	assert(o.Avail() == 1, "avail: exp 1, saw %d", o.Avail())
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		o.Get()
+	}()
+
+	err = o.PutWait(context.Background(), &extra)
+	assert(err == nil, "putwait: %v", err)
+	assert(o.Avail() == 1, "avail: exp 1, saw %d", o.Avail())
+
+	x := o.Get()
+	assert(x == &extra, "exp enqueued obj")
+}
+
+func TestPutNWakesOnlyN(t *testing.T) {
+	assert := newAsserter(t)
+
+	const N = 3
+	const M = 8
+
+	o := objpool.New[int](N)
+	v := o.GetN(N)
+	assert(len(v) == N, "exp %d objs, saw %d", N, len(v))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	served := make(chan *int, M)
+	failed := make(chan error, M)
+	for i := 0; i < M; i++ {
+		go func() {
+			x, err := o.GetContext(ctx)
+			if err != nil {
+				failed <- err
+				return
+			}
+			served <- x
+		}()
+	}
+
+	// let the waiters block
+	time.Sleep(20 * time.Millisecond)
+	o.PutN(v)
+
+	for i := 0; i < N; i++ {
+		select {
+		case <-served:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of %d waiters served", i, N)
+		}
+	}
+
+	// the other waiters must stay asleep
+	select {
+	case <-served:
+		t.Fatalf("more than %d waiters served", N)
+	case err := <-failed:
+		t.Fatalf("waiter woke up early: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancel()
+	for i := 0; i < M-N; i++ {
+		err := <-failed
+		assert(err == context.Canceled, "exp canceled, saw %v", err)
+	}
+	assert(o.Avail() == 0, "avail: exp 0, saw %d", o.Avail())
+}
+
+func TestGetJittered(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](1)
+	a, err := o.GetJittered(context.Background(), time.Millisecond)
+	assert(err == nil && a != nil, "get: %v", err)
+
+	const n = 4
+	got := make(chan *int, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			x, err := o.GetJittered(context.Background(), 2*time.Millisecond)
+			if err != nil {
+				t.Errorf("get: %v", err)
+			}
+			got <- x
+		}()
+	}
+
+	// every waiter is eventually served as objects are returned
+	o.Put(a)
+	for i := 0; i < n; i++ {
+		select {
+		case x := <-got:
+			assert(x == a, "wrong object")
+			o.Put(x)
+		case <-time.After(time.Second):
+			t.Fatalf("waiter %d starved", i)
+		}
+	}
+
+	x := o.Get()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = o.GetJittered(ctx, time.Millisecond)
+	assert(err == context.DeadlineExceeded, "exp deadline, saw %v", err)
+	o.Put(x)
+}
+
+func TestSlowGetThreshold(t *testing.T) {
+	assert := newAsserter(t)
+
+	slow := make(chan time.Duration, 4)
+	o := objpool.New[int](1, objpool.WithSlowGetThreshold[int](20*time.Millisecond, func(d time.Duration) {
+		slow <- d
+	}))
+
+	// fast paths never fire
+	a := o.Get()
+	o.Put(a)
+	a, _ = o.GetContext(context.Background())
+	assert(o.Get() == nil, "get from empty pool")
+
+	go func() {
+		time.Sleep(40 * time.Millisecond)
+		o.Put(a)
+	}()
+	b := o.GetTimeout(time.Second)
+	assert(b == a, "get timeout")
+
+	var d time.Duration
+	select {
+	case d = <-slow:
+	case <-time.After(time.Second):
+		t.Fatalf("slow get not reported")
+	}
+	assert(d >= 40*time.Millisecond, "waited: %s", d)
+
+	// a wait shorter than the threshold doesn't fire
+	x := o.GetTimeout(5 * time.Millisecond)
+	assert(x == nil, "get from empty pool")
+	select {
+	case d = <-slow:
+		t.Fatalf("fast get reported: %s", d)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
@@ -0,0 +1,35 @@
+// whenidle.go - callbacks for when the pool becomes idle
+//
+// Copyright 2024- Sudhi Herle <sw-at-herle-dot-net>
+// License: BSD-2-Clause
+
+package objpool
+
+// WhenIdle registers 'fn' to be called once, in its own goroutine, the
+// next time every object is back in the pool; if the pool is idle
+// already, 'fn' is called right away. WhenIdle never blocks, which
+// suits event driven shutdown: schedule the cleanup to run once all
+// objects have been returned without dedicating a goroutine to wait for
+// it. Every registered callback fires; their relative order is
+// unspecified.
+func (p *Pool[T]) WhenIdle(fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.avail == p.size {
+		go fn()
+		return
+	}
+	p.idleFns = append(p.idleFns, fn)
+	p.replain()
+}
+
+// fireIdle starts the callbacks registered via WhenIdle; the caller
+// must hold the lock.
+func (p *Pool[T]) fireIdle() {
+	for _, fn := range p.idleFns {
+		go fn()
+	}
+	p.idleFns = nil
+	p.replain()
+}
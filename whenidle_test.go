@@ -0,0 +1,56 @@
+package objpool_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opencoff/go-objpool"
+)
+
+func TestWhenIdle(t *testing.T) {
+	assert := newAsserter(t)
+
+	o := objpool.New[int](2)
+	a := o.Get()
+	b := o.Get()
+
+	ch := make(chan int, 3)
+	o.WhenIdle(func() { ch <- 1 })
+	o.WhenIdle(func() { ch <- 2 })
+
+	o.Put(a)
+	select {
+	case <-ch:
+		t.Fatalf("callback fired on a busy pool")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	o.Put(b)
+	var sum int
+	for i := 0; i < 2; i++ {
+		select {
+		case n := <-ch:
+			sum += n
+		case <-time.After(time.Second):
+			t.Fatalf("callback %d didn't fire", i)
+		}
+	}
+	assert(sum == 3, "callbacks: exp both, saw %d", sum)
+
+	// one-shot
+	o.Put(o.Get())
+	select {
+	case <-ch:
+		t.Fatalf("callback fired twice")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// an idle pool fires right away
+	o.WhenIdle(func() { ch <- 4 })
+	select {
+	case n := <-ch:
+		assert(n == 4, "wrong callback %d", n)
+	case <-time.After(time.Second):
+		t.Fatalf("callback on idle pool didn't fire")
+	}
+}